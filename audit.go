@@ -0,0 +1,227 @@
+package chiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultAuditMaxBytes is how much of each body AuditLogger captures by
+// default.
+const DefaultAuditMaxBytes = 64 << 10
+
+// redacted replaces the values of redacted JSON fields.
+const redacted = "[REDACTED]"
+
+// Key to use when setting the per-request audit state.
+type ctxKeyAudit int
+
+const auditKey ctxKeyAudit = 0
+
+// AuditOption configures AuditLogger.
+type AuditOption func(*auditOptions)
+
+type auditOptions struct {
+	maxBytes int
+	redact   map[string]struct{}
+	raw      bool
+}
+
+// WithAuditMaxBytes captures at most n bytes of each body instead of
+// DefaultAuditMaxBytes. Longer bodies are logged truncated.
+func WithAuditMaxBytes(n int) AuditOption {
+	return func(o *auditOptions) {
+		o.maxBytes = n
+	}
+}
+
+// WithRedactedFields replaces the values of the given JSON object keys,
+// matched case-insensitively at any depth, with "[REDACTED]".
+func WithRedactedFields(keys ...string) AuditOption {
+	return func(o *auditOptions) {
+		for _, k := range keys {
+			o.redact[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// WithRawAuditBodies logs bodies that cannot be redacted, because they are
+// not JSON or were truncated, base64 encoded instead of omitting them. Their
+// redacted fields are logged as sent, so only enable it when such bodies
+// carry no secrets.
+func WithRawAuditBodies() AuditOption {
+	return func(o *auditOptions) {
+		o.raw = true
+	}
+}
+
+// audit is the capture state AuditLogger shares with Audit.
+type audit struct {
+	enabled      bool
+	maxBytes     int
+	request      []byte
+	reqTruncated bool
+	response     capture
+}
+
+// capture buffers up to maxBytes of the response once the route is audited.
+type capture struct {
+	audit     *audit
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if !c.audit.enabled {
+		return len(p), nil
+	}
+	n := min(len(p), c.audit.maxBytes-c.buf.Len())
+	c.buf.Write(p[:n])
+	c.truncated = c.truncated || n < len(p)
+	return len(p), nil
+}
+
+// AuditLogger is a middleware that logs the request and response bodies of
+// routes marked with Audit, for compliance audit trails. Unmarked routes are
+// not captured. Install it with Use on the router, after middlewares that
+// consume the body:
+//
+//	r.Use(chiserver.AuditLogger(auditLog, chiserver.WithRedactedFields("password")))
+//	r.With(chiserver.Audit).Post("/transfers", createTransfer)
+//
+// Each audited request is logged at Info as "audit" with its method, path,
+// status and correlation ID. Bodies are captured up to DefaultAuditMaxBytes;
+// complete JSON bodies are logged with redacted fields. Of other bodies only
+// the size, truncation and content type are logged, unless WithRawAuditBodies
+// is set.
+func AuditLogger(logger *slog.Logger, opts ...AuditOption) func(next http.Handler) http.Handler {
+	o := &auditOptions{maxBytes: DefaultAuditMaxBytes, redact: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			a := &audit{maxBytes: o.maxBytes}
+			a.response.audit = a
+			r = r.WithContext(context.WithValue(r.Context(), auditKey, a))
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&a.response)
+			next.ServeHTTP(ww, r)
+			if !a.enabled {
+				return
+			}
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "audit",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", ww.Status()),
+				slog.String("correlation_id", GetCorrID(r.Context())),
+				o.bodyGroup("request", r.Header.Get("Content-Type"), a.request, a.reqTruncated),
+				o.bodyGroup("response", ww.Header().Get("Content-Type"), a.response.buf.Bytes(), a.response.truncated),
+			)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Audit marks a route or group for AuditLogger. It captures the beginning of
+// the request body up front, so it is logged however much the handler reads.
+// Without AuditLogger it does nothing.
+func Audit(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		a, ok := r.Context().Value(auditKey).(*audit)
+		if !ok || a.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.enabled = true
+
+		// Read one extra byte so truncation is detectable.
+		head, err := io.ReadAll(io.LimitReader(r.Body, int64(a.maxBytes)+1))
+		if len(head) > a.maxBytes {
+			a.request, a.reqTruncated = head[:a.maxBytes], true
+		} else {
+			a.request = head
+		}
+		r.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(head), errReader{err}, r.Body), body: r.Body}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// replayBody serves the captured head of a body before the rest of it.
+type replayBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *replayBody) Close() error {
+	return b.body.Close()
+}
+
+// errReader reports err, if any, once the bytes read before it are consumed.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+// bodyGroup describes a captured body as a log group. Bodies that cannot be
+// redacted are only described, unless raw bodies were enabled.
+func (o *auditOptions) bodyGroup(key, contentType string, body []byte, truncated bool) slog.Attr {
+	attrs := []any{slog.Int("captured_bytes", len(body))}
+	if truncated {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+	if contentType != "" {
+		attrs = append(attrs, slog.String("content_type", contentType))
+	}
+	if len(body) == 0 {
+		return slog.Group(key, attrs...)
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if !truncated && dec.Decode(&v) == nil && !dec.More() {
+		if out, err := json.Marshal(o.redactJSON(v)); err == nil {
+			return slog.Group(key, append(attrs, slog.String("encoding", "json"), slog.String("body", string(out)))...)
+		}
+	}
+	if !o.raw {
+		return slog.Group(key, attrs...)
+	}
+	return slog.Group(key, append(attrs, slog.String("encoding", "base64"), slog.String("body", base64.StdEncoding.EncodeToString(body)))...)
+}
+
+// redactJSON replaces the values of redacted keys in a decoded JSON value.
+func (o *auditOptions) redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if _, ok := o.redact[strings.ToLower(k)]; ok {
+				v[k] = redacted
+				continue
+			}
+			v[k] = o.redactJSON(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = o.redactJSON(val)
+		}
+	}
+	return v
+}
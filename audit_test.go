@@ -0,0 +1,155 @@
+package chiserver_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// auditEntry is the part of an audit log line the tests inspect
+type auditEntry struct {
+	Msg           string `json:"msg"`
+	Path          string `json:"path"`
+	Status        int    `json:"status"`
+	CorrelationID string `json:"correlation_id"`
+	Request       struct {
+		CapturedBytes int    `json:"captured_bytes"`
+		ContentType   string `json:"content_type"`
+		Encoding      string `json:"encoding"`
+		Body          string `json:"body"`
+		Truncated     bool   `json:"truncated"`
+	} `json:"request"`
+	Response struct {
+		CapturedBytes int    `json:"captured_bytes"`
+		ContentType   string `json:"content_type"`
+		Encoding      string `json:"encoding"`
+		Body          string `json:"body"`
+		Truncated     bool   `json:"truncated"`
+	} `json:"response"`
+}
+
+// newAuditRouter returns a router auditing /audited but not /plain, echoing request bodies
+func newAuditRouter(logger *slog.Logger, opts ...chiserver.AuditOption) http.Handler {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}
+
+	r := chi.NewRouter()
+	r.Use(chiserver.CorrelationID, chiserver.AuditLogger(logger, opts...))
+	r.With(chiserver.Audit).Post("/audited", echo)
+	r.Post("/plain", echo)
+	return r
+}
+
+// TestAuditLogger_RedactsJSON tests that marked routes log both bodies with redacted fields and the
+// correlation ID
+func TestAuditLogger_RedactsJSON(t *testing.T) {
+	var buf strings.Builder
+	router := newAuditRouter(slog.New(slog.NewJSONHandler(&buf, nil)), chiserver.WithRedactedFields("Password"))
+
+	req := httptest.NewRequest(http.MethodPost, "/audited", strings.NewReader(`{"user":"ada","password":"hunter2","nested":[{"password":"x"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(chiserver.CorrelationIDHeader, "audit-corr-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("Expected the handler to receive the unredacted body, got %q", w.Body.String())
+	}
+
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(buf.String()), &entry); err != nil {
+		t.Fatalf("Expected one JSON audit line, got %q: %v", buf.String(), err)
+	}
+	if entry.Msg != "audit" || entry.Path != "/audited" || entry.Status != http.StatusCreated || entry.CorrelationID != "audit-corr-1" {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+	expected := `{"nested":[{"password":"[REDACTED]"}],"password":"[REDACTED]","user":"ada"}`
+	if entry.Request.Encoding != "json" || entry.Request.Body != expected {
+		t.Errorf("Expected redacted JSON request body %s, got %s %s", expected, entry.Request.Encoding, entry.Request.Body)
+	}
+	if entry.Response.Encoding != "json" || entry.Response.Body != expected {
+		t.Errorf("Expected redacted JSON response body %s, got %s %s", expected, entry.Response.Encoding, entry.Response.Body)
+	}
+}
+
+// TestAuditLogger_TruncatedJSONNotLogged tests that a truncated JSON body, which cannot be redacted,
+// is logged by its metadata only
+func TestAuditLogger_TruncatedJSONNotLogged(t *testing.T) {
+	var buf strings.Builder
+	router := newAuditRouter(slog.New(slog.NewJSONHandler(&buf, nil)),
+		chiserver.WithRedactedFields("password"), chiserver.WithAuditMaxBytes(24))
+
+	req := httptest.NewRequest(http.MethodPost, "/audited", strings.NewReader(`{"user":"ada","password":"hunter2","padding":"xxxxxxxx"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("Expected the redacted field not to be logged, got %s", buf.String())
+	}
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(buf.String()), &entry); err != nil {
+		t.Fatalf("Expected one JSON audit line, got %q: %v", buf.String(), err)
+	}
+	if entry.Request.Body != "" || entry.Request.Encoding != "" {
+		t.Errorf("Expected no request body, got %s %s", entry.Request.Encoding, entry.Request.Body)
+	}
+	if !entry.Request.Truncated || entry.Request.CapturedBytes != 24 || entry.Request.ContentType != "application/json" {
+		t.Errorf("Expected request metadata, got %+v", entry.Request)
+	}
+	if entry.Response.Body != "" || !entry.Response.Truncated {
+		t.Errorf("Expected truncated response without body, got %+v", entry.Response)
+	}
+}
+
+// TestAuditLogger_RawBodies tests that with WithRawAuditBodies non-JSON bodies are base64 encoded and
+// long bodies truncated without affecting the handler
+func TestAuditLogger_RawBodies(t *testing.T) {
+	var buf strings.Builder
+	router := newAuditRouter(slog.New(slog.NewJSONHandler(&buf, nil)), chiserver.WithAuditMaxBytes(4), chiserver.WithRawAuditBodies())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/audited", strings.NewReader("binary-data")))
+	if w.Body.String() != "binary-data" {
+		t.Errorf("Expected the full body to reach the handler, got %q", w.Body.String())
+	}
+
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(buf.String()), &entry); err != nil {
+		t.Fatalf("Expected one JSON audit line, got %q: %v", buf.String(), err)
+	}
+	expected := base64.StdEncoding.EncodeToString([]byte("bina"))
+	if entry.Request.Encoding != "base64" || entry.Request.Body != expected || !entry.Request.Truncated {
+		t.Errorf("Expected truncated base64 request body %s, got %+v", expected, entry.Request)
+	}
+	if entry.Response.Encoding != "base64" || entry.Response.Body != expected || !entry.Response.Truncated {
+		t.Errorf("Expected truncated base64 response body %s, got %+v", expected, entry.Response)
+	}
+}
+
+// TestAuditLogger_SkipsUnmarkedRoutes tests that routes without Audit are not logged
+func TestAuditLogger_SkipsUnmarkedRoutes(t *testing.T) {
+	var buf strings.Builder
+	router := newAuditRouter(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/plain", strings.NewReader("secret")))
+	if w.Body.String() != "secret" {
+		t.Errorf("Expected the handler to run, got %q", w.Body.String())
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no audit log for unmarked routes, got %q", buf.String())
+	}
+}
@@ -0,0 +1,121 @@
+package chiserver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchWriter buffers log records and writes them to the underlying writer in
+// batches, cutting per-request I/O at high request rates. Each Write call is
+// treated as one record, which matches how slog handlers emit lines.
+//
+// Build the request logger on top of it and flush on shutdown so no records
+// are lost:
+//
+//	bw := chiserver.NewBatchWriter(os.Stdout, 100, time.Second)
+//	logger := slog.New(slog.NewJSONHandler(bw, nil))
+//	server := chiserver.NewServer(chiserver.Config{Logger: logger}, routes)
+//	server.OnShutdown(bw.Shutdown)
+type BatchWriter struct {
+	w    io.Writer
+	size int
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	records int
+	closed  bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewBatchWriter returns a BatchWriter that flushes to w whenever size records
+// are buffered, and at least every interval when it is positive.
+func NewBatchWriter(w io.Writer, size int, interval time.Duration) *BatchWriter {
+	b := &BatchWriter{
+		w:    w,
+		size: size,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go b.loop(interval)
+	return b
+}
+
+// Write buffers one record, flushing if the batch is full. After Close it
+// returns os.ErrClosed, since the record would never be flushed.
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+
+	b.buf.Write(p)
+	b.records++
+	if b.records >= b.size {
+		if err := b.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (b *BatchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+// Close stops the periodic flush and writes the final partial batch. It is
+// safe to call more than once and concurrently.
+func (b *BatchWriter) Close() error {
+	b.stopOnce.Do(func() { close(b.stop) })
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return b.flushLocked()
+}
+
+// Shutdown is Close with a signature suitable for Server.OnShutdown.
+func (b *BatchWriter) Shutdown(context.Context) error {
+	return b.Close()
+}
+
+func (b *BatchWriter) flushLocked() error {
+	if b.records == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf.Bytes())
+	b.buf.Reset()
+	b.records = 0
+	return err
+}
+
+func (b *BatchWriter) loop(interval time.Duration) {
+	defer close(b.done)
+	if interval <= 0 {
+		<-b.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// batchRecorder records every write it receives as a separate batch
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []string
+}
+
+func (b *batchRecorder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, string(p))
+	return len(p), nil
+}
+
+func (b *batchRecorder) snapshot() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.batches...)
+}
+
+// TestBatchWriter_FlushesInBatches tests that request logs are written in full batches plus a final flush
+func TestBatchWriter_FlushesInBatches(t *testing.T) {
+	out := &batchRecorder{}
+	bw := chiserver.NewBatchWriter(out, 3, time.Hour)
+	logger := slog.New(slog.NewJSONHandler(bw, nil))
+
+	handler := chiserver.RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 7; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	wg.Wait()
+
+	batches := out.snapshot()
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 full batches before close, got %d", len(batches))
+	}
+	for _, batch := range batches {
+		if n := bytes.Count([]byte(batch), []byte("\n")); n != 3 {
+			t.Errorf("Expected 3 records per batch, got %d", n)
+		}
+	}
+
+	if err := bw.Close(); err != nil {
+		t.Fatalf("Expected clean close, got: %v", err)
+	}
+
+	batches = out.snapshot()
+	if len(batches) != 3 {
+		t.Fatalf("Expected final flush to add a partial batch, got %d batches", len(batches))
+	}
+	if n := bytes.Count([]byte(batches[2]), []byte("\n")); n != 1 {
+		t.Errorf("Expected final batch to hold the remaining record, got %d", n)
+	}
+}
+
+// TestBatchWriter_FlushesOnInterval tests that a partial batch is flushed after the interval
+func TestBatchWriter_FlushesOnInterval(t *testing.T) {
+	out := &batchRecorder{}
+	bw := chiserver.NewBatchWriter(out, 100, 20*time.Millisecond)
+	defer bw.Close()
+
+	bw.Write([]byte("record\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(out.snapshot()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected partial batch to be flushed on interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestBatchWriter_ConcurrentClose tests that Close and Shutdown can race without panicking
+func TestBatchWriter_ConcurrentClose(t *testing.T) {
+	out := &batchRecorder{}
+	bw := chiserver.NewBatchWriter(out, 100, time.Second)
+	bw.Write([]byte("record\n"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			bw.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			bw.Shutdown(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if got := out.snapshot(); len(got) != 1 || got[0] != "record\n" {
+		t.Errorf("Expected the record to be flushed once, got %q", got)
+	}
+}
+
+// TestBatchWriter_WriteAfterClose tests that writes after Close fail instead of being buffered
+func TestBatchWriter_WriteAfterClose(t *testing.T) {
+	out := &batchRecorder{}
+	bw := chiserver.NewBatchWriter(out, 100, 0)
+	bw.Write([]byte("record\n"))
+	bw.Close()
+
+	if n, err := bw.Write([]byte("late\n")); n != 0 || !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected 0, os.ErrClosed, got %d, %v", n, err)
+	}
+	bw.Close()
+	if got := out.snapshot(); len(got) != 1 || got[0] != "record\n" {
+		t.Errorf("Expected only the record written before Close, got %q", got)
+	}
+}
@@ -0,0 +1,336 @@
+package chiserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ValidateContentLength is a middleware that reads bodies with a declared
+// Content-Length up front and rejects the request with 400 when the actual
+// body size differs. Declared lengths above maxBytes are rejected with 413.
+// Requests without a declared length (chunked or empty) pass through untouched.
+func ValidateContentLength(maxBytes int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			// Allow one extra byte so an over-long body is detectable.
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, r.ContentLength+1))
+			if err != nil || int64(len(body)) != r.ContentLength {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// MaxBodyBytes is a middleware that limits request bodies to n bytes.
+// Requests declaring a larger Content-Length are rejected up front with
+// 413 Request Entity Too Large. Other bodies are wrapped in
+// http.MaxBytesReader: reading past the limit fails with *http.MaxBytesError
+// and the client gets 413 in place of whatever the handler answers, unless
+// the handler had already started its response.
+func MaxBodyBytes(n int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			tw := &tooLargeWriter{ResponseWriter: w}
+			r.Body = tw.watch(http.MaxBytesReader(w, r.Body, n))
+			next.ServeHTTP(tw, r)
+			tw.finish()
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// tooLargeWriter answers 413 in place of the handler's response once a body
+// it watches hit its limit.
+type tooLargeWriter struct {
+	http.ResponseWriter
+	exceeded    bool
+	wroteHeader bool
+	rejected    bool
+}
+
+// watch returns body reporting to tw when reading it fails with
+// *http.MaxBytesError.
+func (tw *tooLargeWriter) watch(body io.ReadCloser) io.ReadCloser {
+	return &watchedBody{ReadCloser: body, tw: tw}
+}
+
+func (tw *tooLargeWriter) WriteHeader(code int) {
+	switch {
+	case tw.rejected:
+	case !tw.wroteHeader && tw.exceeded:
+		tw.reject()
+	default:
+		if code >= http.StatusOK {
+			tw.wroteHeader = true
+		}
+		tw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (tw *tooLargeWriter) Write(p []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.rejected {
+		// The handler's response is dropped in favor of the 413.
+		return len(p), nil
+	}
+	return tw.ResponseWriter.Write(p)
+}
+
+func (tw *tooLargeWriter) Flush() {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok && !tw.rejected {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over for protocol upgrades such as
+// websockets, which type-assert http.Hijacker.
+func (tw *tooLargeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := tw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		tw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (tw *tooLargeWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// finish answers 413 when the limit was hit and the handler wrote nothing.
+func (tw *tooLargeWriter) finish() {
+	if !tw.wroteHeader && tw.exceeded {
+		tw.reject()
+	}
+}
+
+func (tw *tooLargeWriter) reject() {
+	tw.wroteHeader, tw.rejected = true, true
+	http.Error(tw.ResponseWriter, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
+
+// watchedBody flags its tooLargeWriter when the body limit is hit.
+type watchedBody struct {
+	io.ReadCloser
+	tw *tooLargeWriter
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.tw.exceeded = true
+	}
+	return n, err
+}
+
+// Key to use when setting the per-request body limit.
+type ctxKeyBodyLimit int
+
+const bodyLimitKey ctxKeyBodyLimit = 0
+
+// bodyLimit is the limit BodyLimits enforces, adjustable by BodyLimit until
+// the body is first read.
+type bodyLimit struct {
+	n int64
+}
+
+// limitedBody applies the request's body limit when the body is first read,
+// so routes can set it after BodyLimits has run.
+type limitedBody struct {
+	w     http.ResponseWriter
+	body  io.ReadCloser
+	limit *bodyLimit
+	r     io.ReadCloser
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.r == nil {
+		b.r = http.MaxBytesReader(b.w, b.body, b.limit.n)
+	}
+	return b.r.Read(p)
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// BodyLimits is a middleware that limits request bodies to defaultLimit bytes
+// unless the matched route declares its own limit with BodyLimit, which may
+// raise or lower it. Install it with Use on the router:
+//
+//	r.Use(chiserver.BodyLimits(1 << 20))
+//	r.With(chiserver.BodyLimit(4 << 10)).Post("/login", login)
+//	r.With(chiserver.BodyLimit(100 << 20)).Post("/upload", upload)
+//
+// Reading past the limit fails with *http.MaxBytesError and the client gets
+// 413, as with MaxBodyBytes.
+func BodyLimits(defaultLimit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			limit := &bodyLimit{n: defaultLimit}
+			r = r.WithContext(context.WithValue(r.Context(), bodyLimitKey, limit))
+			tw := &tooLargeWriter{ResponseWriter: w}
+			r.Body = tw.watch(&limitedBody{w: w, body: r.Body, limit: limit})
+			next.ServeHTTP(tw, r)
+			tw.finish()
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// BodyLimit declares the maximum body size of a route or group. Requests
+// declaring a larger Content-Length are rejected with 413 up front. Under
+// BodyLimits it replaces the default limit; on its own it behaves like
+// MaxBodyBytes.
+func BodyLimit(n int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if limit, ok := r.Context().Value(bodyLimitKey).(*bodyLimit); ok {
+				limit.n = n
+				next.ServeHTTP(w, r)
+				return
+			}
+			tw := &tooLargeWriter{ResponseWriter: w}
+			r.Body = tw.watch(http.MaxBytesReader(w, r.Body, n))
+			next.ServeHTTP(tw, r)
+			tw.finish()
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ExpectContinue is a middleware that calls decide to vet requests sent with
+// "Expect: 100-continue" before the client transmits the body. decide returns
+// 0 to accept the request, or the status to reject it with, e.g. 413 for an
+// oversized Content-Length or 417 Expectation Failed. Rejected requests are
+// answered without reading the body, so net/http never sends 100 Continue
+// and the client does not upload it. Requests without the expectation pass
+// through untouched.
+func ExpectContinue(decide func(r *http.Request) int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !strings.EqualFold(r.Header.Get("Expect"), "100-continue") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if status := decide(r); status != 0 {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ChecksumOption configures VerifyChecksum.
+type ChecksumOption func(*checksumOptions)
+
+type checksumOptions struct {
+	required bool
+}
+
+// RequireChecksum makes VerifyChecksum reject requests without the checksum
+// header with 400 instead of letting them through.
+func RequireChecksum() ChecksumOption {
+	return func(o *checksumOptions) {
+		o.required = true
+	}
+}
+
+// VerifyChecksum is a middleware that checks the request body against the
+// digest sent in header, e.g. VerifyChecksum("Content-MD5", md5.New). The
+// digest may be base64 (as in Content-MD5) or hex encoded. Mismatches are
+// rejected with 400; the verified body is replayed to the handler.
+//
+// The whole body is buffered in memory, so combine it with a body size limit.
+func VerifyChecksum(header string, newHash func() hash.Hash, opts ...ChecksumOption) func(next http.Handler) http.Handler {
+	o := &checksumOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			expected := r.Header.Get(header)
+			if expected == "" {
+				if o.required {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			h := newHash()
+			h.Write(body)
+			if !digestMatches(h.Sum(nil), expected) {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// digestMatches compares sum with a base64 or hex encoded digest.
+func digestMatches(sum []byte, encoded string) bool {
+	if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(decoded) == len(sum) {
+		return subtle.ConstantTimeCompare(decoded, sum) == 1
+	}
+	if decoded, err := hex.DecodeString(encoded); err == nil {
+		return subtle.ConstantTimeCompare(decoded, sum) == 1
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+package chiserver_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestValidateContentLength_MatchingLength tests that a body matching its declared length passes
+func TestValidateContentLength_MatchingLength(t *testing.T) {
+	handler := chiserver.ValidateContentLength(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("Expected handler to read 'hello', got %q", body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+// TestValidateContentLength_ShortBody tests that a body shorter than declared is rejected
+func TestValidateContentLength_ShortBody(t *testing.T) {
+	called := false
+	handler := chiserver.ValidateContentLength(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello"))
+	req.ContentLength = 10
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if called {
+		t.Error("Expected handler not to run on length mismatch")
+	}
+}
+
+// TestMaxBodyBytes_DeclaredTooLarge tests that an oversized declared body is rejected and logged
+func TestMaxBodyBytes_DeclaredTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger)(chiserver.MaxBodyBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to run for oversized body")
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("way more than eight bytes"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), `"status":413`) {
+		t.Errorf("Expected log to record status 413, got: %s", buf.String())
+	}
+}
+
+// TestMaxBodyBytes_UndeclaredLength tests that reads past the limit fail for bodies without a length
+// and the middleware answers 413 whatever the handler does
+func TestMaxBodyBytes_UndeclaredLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"handler writes nothing", func(w http.ResponseWriter, r *http.Request) {}},
+		{"handler answers 400", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "cannot read body", http.StatusBadRequest)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := chiserver.MaxBodyBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.ReadAll(r.Body)
+				var maxErr *http.MaxBytesError
+				if !errors.As(err, &maxErr) {
+					t.Errorf("Expected *http.MaxBytesError, got: %v", err)
+				}
+				tt.handler(w, r)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("way more than eight bytes"))
+			req.ContentLength = -1
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("Expected status 413, got %d", w.Code)
+			}
+			if strings.Contains(w.Body.String(), "cannot read body") {
+				t.Errorf("Expected the handler's response to be dropped, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestMaxBodyBytes_WithinLimit tests that small bodies pass through
+func TestMaxBodyBytes_WithinLimit(t *testing.T) {
+	handler := chiserver.MaxBodyBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || string(body) != "small" {
+			t.Errorf("Expected to read 'small', got %q (%v)", body, err)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("small"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// TestMaxBodyBytes_Hijack tests that handlers can still hijack the connection, as websocket
+// upgrades do
+func TestMaxBodyBytes_Hijack(t *testing.T) {
+	srv := httptest.NewServer(chiserver.MaxBodyBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("Expected the writer to implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("Expected hijack to succeed, got: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Expected an upgrade response, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101, got %d", resp.StatusCode)
+	}
+}
+
+// TestBodyLimit_PerRoute tests that routes enforce their own body limits independently of the default
+func TestBodyLimit_PerRoute(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(chiserver.BodyLimits(16))
+
+	// Handlers answer read errors with 400; the middleware must turn
+	// oversized bodies into 413 itself.
+	readBody := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+	r.With(chiserver.BodyLimit(8)).Post("/login", readBody)
+	r.With(chiserver.BodyLimit(64)).Post("/upload", readBody)
+	r.Post("/other", readBody)
+
+	tests := []struct {
+		path     string
+		size     int
+		declared bool
+		expected int
+	}{
+		{"/login", 8, false, http.StatusOK},
+		{"/login", 10, false, http.StatusRequestEntityTooLarge},
+		{"/login", 10, true, http.StatusRequestEntityTooLarge},
+		{"/upload", 40, false, http.StatusOK},
+		{"/upload", 65, false, http.StatusRequestEntityTooLarge},
+		{"/other", 16, false, http.StatusOK},
+		{"/other", 20, false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+		if !tt.declared {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s with %d bytes (declared=%v): expected status %d, got %d", tt.path, tt.size, tt.declared, tt.expected, w.Code)
+		}
+	}
+}
+
+// TestBodyLimit_Standalone tests that BodyLimit without BodyLimits answers oversized bodies with 413
+func TestBodyLimit_Standalone(t *testing.T) {
+	handler := chiserver.BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		var maxErr *http.MaxBytesError
+		if !errors.As(err, &maxErr) {
+			t.Errorf("Expected *http.MaxBytesError, got: %v", err)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("way more than eight bytes"))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+}
+
+// TestExpectContinue_RejectsBeforeBody tests that an oversized upload is rejected without a 100 Continue
+func TestExpectContinue_RejectsBeforeBody(t *testing.T) {
+	handler := chiserver.ExpectContinue(func(r *http.Request) int {
+		if r.ContentLength > 1024 {
+			return http.StatusRequestEntityTooLarge
+		}
+		return 0
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to run for rejected upload")
+	}))
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// Send headers only: the client waits for 100 Continue before the body.
+	fmt.Fprint(conn, "POST /upload HTTP/1.1\r\nHost: example.com\r\nExpect: 100-continue\r\nContent-Length: 10485760\r\n\r\n")
+
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if !strings.HasPrefix(status, "HTTP/1.1 413") {
+		t.Errorf("Expected early 413 response, got %q", status)
+	}
+}
+
+// TestVerifyChecksum_Matching tests that a matching checksum passes and the body is replayed
+func TestVerifyChecksum_Matching(t *testing.T) {
+	payload := "important data"
+	sum := md5.Sum([]byte(payload))
+
+	handler := chiserver.VerifyChecksum("Content-MD5", md5.New)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != payload {
+			t.Errorf("Expected handler to read %q, got %q", payload, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader(payload))
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+// TestVerifyChecksum_Mismatch tests that a mismatching checksum is rejected
+func TestVerifyChecksum_Mismatch(t *testing.T) {
+	sum := sha256.Sum256([]byte("original"))
+
+	handler := chiserver.VerifyChecksum("X-Checksum-SHA256", sha256.New)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler not to run on checksum mismatch")
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader("tampered"))
+	req.Header.Set("X-Checksum-SHA256", hex.EncodeToString(sum[:]))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// TestVerifyChecksum_MissingHeader tests that a missing header passes unless a checksum is required
+func TestVerifyChecksum_MissingHeader(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		opts     []chiserver.ChecksumOption
+		expected int
+	}{
+		{nil, http.StatusOK},
+		{[]chiserver.ChecksumOption{chiserver.RequireChecksum()}, http.StatusBadRequest},
+	} {
+		handler := chiserver.VerifyChecksum("Content-MD5", md5.New, tc.opts...)(next)
+
+		req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader("data"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expected {
+			t.Errorf("Expected status %d, got %d", tc.expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package chiserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DeadlineBudget is a middleware that caps the handler time spent across all
+// requests within a sliding window, e.g. to protect a shared expensive
+// backend. Handler time of requests that finished within the last window, plus
+// the time in-flight requests have run so far, counts against total. While the
+// budget is exhausted new requests get 503 Service Unavailable with a
+// Retry-After header.
+func DeadlineBudget(total, window time.Duration) func(next http.Handler) http.Handler {
+	b := &deadlineBudget{total: total, window: window, inFlight: make(map[uint64]time.Time)}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id, retryAfter, ok := b.start(time.Now())
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { b.finish(id, time.Now()) }()
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// budgetEntry is the handler time of a finished request.
+type budgetEntry struct {
+	end     time.Time
+	elapsed time.Duration
+}
+
+// deadlineBudget tracks handler time consumed within the sliding window.
+type deadlineBudget struct {
+	total  time.Duration
+	window time.Duration
+
+	mu       sync.Mutex
+	done     []budgetEntry // ordered by end
+	inFlight map[uint64]time.Time
+	nextID   uint64
+}
+
+// start admits a request starting at now, or reports how long until the
+// oldest finished request leaves the window.
+func (b *deadlineBudget) start(now time.Time) (uint64, time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := now.Add(-b.window)
+	expired := 0
+	for expired < len(b.done) && !b.done[expired].end.After(cutoff) {
+		expired++
+	}
+	b.done = b.done[expired:]
+
+	var used time.Duration
+	for _, e := range b.done {
+		used += e.elapsed
+	}
+	for _, started := range b.inFlight {
+		used += now.Sub(started)
+	}
+	if used >= b.total {
+		retryAfter := time.Second
+		if len(b.done) > 0 {
+			retryAfter = max(b.done[0].end.Sub(cutoff), retryAfter)
+		}
+		return 0, retryAfter, false
+	}
+
+	b.nextID++
+	b.inFlight[b.nextID] = now
+	return b.nextID, 0, true
+}
+
+// finish records the handler time of the request admitted as id.
+func (b *deadlineBudget) finish(id uint64, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.done = append(b.done, budgetEntry{end: now, elapsed: now.Sub(b.inFlight[id])})
+	delete(b.inFlight, id)
+}
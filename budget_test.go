@@ -0,0 +1,96 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// newBudgetHandler returns a handler sleeping for the duration in the delay query parameter
+func newBudgetHandler(total, window time.Duration) http.Handler {
+	return chiserver.DeadlineBudget(total, window)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, err := time.ParseDuration(r.URL.Query().Get("delay")); err == nil {
+			time.Sleep(d)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// serveBudget sends a GET request with the given delay and returns the status code
+func serveBudget(h http.Handler, delay string) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?delay="+delay, nil))
+	return w.Code
+}
+
+// TestDeadlineBudget_RejectsWhenExhausted tests that slow requests saturating the budget cause 503 for new ones
+func TestDeadlineBudget_RejectsWhenExhausted(t *testing.T) {
+	h := newBudgetHandler(200*time.Millisecond, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code := serveBudget(h, "100ms"); code != http.StatusOK {
+				t.Errorf("Expected slow request within budget to succeed, got %d", code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 once the budget is exhausted, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on rejection")
+	}
+}
+
+// TestDeadlineBudget_CountsInFlight tests that time consumed by running requests counts against the budget
+func TestDeadlineBudget_CountsInFlight(t *testing.T) {
+	overBudget := make(chan struct{})
+	release := make(chan struct{})
+	h := chiserver.DeadlineBudget(50*time.Millisecond, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("delay") == "" {
+			time.Sleep(100 * time.Millisecond)
+			close(overBudget)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-overBudget
+
+	if code := serveBudget(h, "0s"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while an in-flight request exceeds the budget, got %d", code)
+	}
+	close(release)
+	<-done
+}
+
+// TestDeadlineBudget_RecoversAfterWindow tests that consumed time stops counting once it leaves the window
+func TestDeadlineBudget_RecoversAfterWindow(t *testing.T) {
+	h := newBudgetHandler(50*time.Millisecond, 200*time.Millisecond)
+
+	serveBudget(h, "60ms")
+	if code := serveBudget(h, "0s"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 right after exhausting the budget, got %d", code)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if code := serveBudget(h, "0s"); code != http.StatusOK {
+		t.Errorf("Expected 200 once the window has passed, got %d", code)
+	}
+}
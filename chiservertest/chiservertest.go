@@ -0,0 +1,57 @@
+// Package chiservertest provides utilities for testing services built on
+// chiserver.
+package chiservertest
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// StartTestServer runs a chiserver.Server for cfg and routes on an ephemeral
+// loopback port and returns its base URL with a client for it. The listener
+// is bound before StartTestServer returns, so requests can be sent right
+// away. The server is shut down when the test ends; a failed shutdown fails
+// the test.
+//
+// cfg.Addr and cfg.Listener are ignored. When cfg.CertFile is set the server
+// speaks HTTPS and the client skips certificate verification.
+func StartTestServer(t testing.TB, cfg chiserver.Config, routes chiserver.RouteConfigurator) (baseURL string, client *http.Client) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("chiservertest: failed to listen: %v", err)
+	}
+	cfg.Addr = ""
+	cfg.Listener = ln
+	server := chiserver.NewServer(cfg, routes)
+
+	transport := &http.Transport{}
+	baseURL = "http://" + ln.Addr().String()
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		baseURL = "https://" + ln.Addr().String()
+	}
+	client = &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		transport.CloseIdleConnections()
+		cancel()
+		if err := <-errCh; err != nil {
+			t.Errorf("chiservertest: server stopped with error: %v", err)
+		}
+	})
+
+	return baseURL, client
+}
@@ -0,0 +1,35 @@
+package chiservertest_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+	"github.com/pmatteo/chi_server/chiservertest"
+)
+
+// TestStartTestServer tests that the server accepts requests as soon as the helper returns
+func TestStartTestServer(t *testing.T) {
+	baseURL, client := chiservertest.StartTestServer(t, chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {
+		r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello"))
+		})
+	})
+
+	resp, err := client.Get(baseURL + "/hello")
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Errorf("Expected 200 'hello', got %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,88 @@
+package chiserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Access log formats NewServer can emit, selected with Config.AccessLogFormat.
+const (
+	AccessLogSlog     = "slog"
+	AccessLogCombined = "combined"
+	AccessLogECS      = "ecs"
+)
+
+// clfTimeFormat is the timestamp layout of the Common Log Format.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// RequestLoggerCLF logs each HTTP request to w as an NCSA Combined Log Format
+// line, for pipelines that expect Apache-style access logs:
+//
+//	203.0.113.7 - - [10/Oct/2025:13:55:36 +0000] "GET /users HTTP/1.1" 200 2326 "-" "curl/8.4.0"
+//
+// Lines are written whole, one per request, so w may be shared. Of the
+// LoggerOptions, only WithSkipPaths applies.
+func RequestLoggerCLF(w io.Writer, opts ...LoggerOption) func(next http.Handler) http.Handler {
+	o := &loggerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			if o.skip(r.URL.Path) {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			user := "-"
+			if name, _, ok := r.BasicAuth(); ok && name != "" {
+				user = name
+			}
+			status := ww.Status()
+			if status == 0 {
+				// Nothing was written; net/http answers 200.
+				status = http.StatusOK
+			}
+			bytes := "-"
+			if n := ww.BytesWritten(); n > 0 {
+				bytes = strconv.Itoa(n)
+			}
+
+			line := fmt.Sprintf("%s - %s [%s] %q %d %s %q %q\n",
+				clientIP(r),
+				user,
+				start.Format(clfTimeFormat),
+				r.Method+" "+r.RequestURI+" "+r.Proto,
+				status,
+				bytes,
+				clfField(r.Referer()),
+				clfField(r.UserAgent()),
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+			io.WriteString(w, line)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clfField returns v, or "-" for missing values as the format requires.
+func clfField(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
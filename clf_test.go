@@ -0,0 +1,60 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+	"github.com/pmatteo/chi_server/chiservertest"
+)
+
+// TestRequestLoggerCLF tests that requests are logged as Combined Log Format lines
+func TestRequestLoggerCLF(t *testing.T) {
+	var buf bytes.Buffer
+	handler := chiserver.RequestLoggerCLF(&buf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users?page=2", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	req.SetBasicAuth("alice", "secret")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	pattern := regexp.MustCompile(`^203\.0\.113\.7 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /users\?page=2 HTTP/1\.1" 201 5 "https://example\.com/" "curl/8\.4\.0"\n$`)
+	if !pattern.MatchString(buf.String()) {
+		t.Errorf("Expected a combined log line, got: %q", buf.String())
+	}
+}
+
+// TestServer_AccessLogFormatCombined tests that NewServer writes combined access logs when configured
+func TestServer_AccessLogFormatCombined(t *testing.T) {
+	var access syncBuffer
+	base, client := chiservertest.StartTestServer(t, chiserver.Config{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AccessLogFormat: chiserver.AccessLogCombined,
+		AccessLogWriter: &access,
+	}, func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	resp, err := client.Get(base + "/ping")
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(access.String(), `"GET /ping HTTP/1.1" 200 - "-" "Go-http-client/1.1"`) {
+		t.Errorf("Expected combined access log line, got: %q", access.String())
+	}
+}
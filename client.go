@@ -0,0 +1,131 @@
+package chiserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// InjectCorrID sets the correlation ID stored in ctx on the outgoing request,
+// so downstream services log the same ID. It uses the header the middleware
+// that set the ID was configured with, or CorrelationIDHeader for IDs set
+// with ContextWithCorrID. It does nothing when ctx carries no correlation ID.
+func InjectCorrID(ctx context.Context, req *http.Request) {
+	if corrID := GetCorrID(ctx); corrID != "" {
+		req.Header.Set(corrIDHeader(ctx), corrID)
+	}
+}
+
+// correlationTransport forwards the correlation ID of each request's context.
+type correlationTransport struct {
+	base http.RoundTripper
+}
+
+// CorrelationTransport wraps base so every request sent through it carries the
+// correlation ID of its context. A nil base uses http.DefaultTransport.
+//
+//	client := &http.Client{Transport: chiserver.CorrelationTransport(nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+//	resp, err := client.Do(req)
+func CorrelationTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &correlationTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *correlationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if GetCorrID(req.Context()) == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	InjectCorrID(req.Context(), req)
+	return t.base.RoundTrip(req)
+}
+
+// DefaultRetryBackoff is the wait before the first retry of RetryTransport;
+// it doubles with every further attempt.
+const DefaultRetryBackoff = 100 * time.Millisecond
+
+// retryTransport retries idempotent requests that failed transiently.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	backoff    time.Duration
+}
+
+// RetryTransport wraps base to retry idempotent requests (GET, HEAD, OPTIONS,
+// PUT, DELETE) up to maxRetries times when the round trip fails or the
+// response is 502, 503 or 504, with exponential backoff. Requests whose body
+// cannot be replayed are not retried. Each retry is counted in the
+// "downstream_retries" field of the request log line of the request context.
+// A nil base uses http.DefaultTransport.
+func RetryTransport(base http.RoundTripper, maxRetries int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, maxRetries: maxRetries, backoff: DefaultRetryBackoff}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if !retryable(req) {
+		return resp, err
+	}
+
+	backoff := t.backoff
+	for attempt := 0; attempt < t.maxRetries && shouldRetry(resp, err); attempt++ {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := Sleep(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		addLogCount(req.Context(), "downstream_retries", 1)
+		resp, err = t.base.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// retryable reports whether req can safely be sent again.
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether a round trip result is a transient failure.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// NewCorrelatingClient returns an HTTP client for calling downstream services
+// from handlers: requests carry the correlation ID of their context and
+// transient failures are retried up to maxRetries times, as by RetryTransport.
+func NewCorrelatingClient(maxRetries int) *http.Client {
+	return &http.Client{Transport: CorrelationTransport(RetryTransport(nil, maxRetries))}
+}
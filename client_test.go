@@ -0,0 +1,114 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestInjectCorrID tests that the context correlation ID is set on the outgoing request
+func TestInjectCorrID(t *testing.T) {
+	ctx := chiserver.ContextWithCorrID(context.Background(), "corr-123")
+	req := httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+
+	chiserver.InjectCorrID(ctx, req)
+
+	if got := req.Header.Get(chiserver.CorrelationIDHeader); got != "corr-123" {
+		t.Errorf("Expected header 'corr-123', got '%s'", got)
+	}
+}
+
+// TestInjectCorrID_MiddlewareHeader tests that the ID is forwarded in the header the middleware was configured with
+func TestInjectCorrID_MiddlewareHeader(t *testing.T) {
+	out := httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+	handler := chiserver.CorrelationIDWithConfig(chiserver.CorrelationIDConfig{HeaderName: "X-Request-ID"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			chiserver.InjectCorrID(r.Context(), out)
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "corr-789")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := out.Header.Get("X-Request-ID"); got != "corr-789" {
+		t.Errorf("Expected X-Request-ID 'corr-789', got '%s'", got)
+	}
+	if got := out.Header.Get(chiserver.CorrelationIDHeader); got != "" {
+		t.Errorf("Expected no default header, got '%s'", got)
+	}
+}
+
+// TestCorrelationTransport tests that clients built with the transport forward the correlation ID
+func TestCorrelationTransport(t *testing.T) {
+	var received string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(chiserver.CorrelationIDHeader)
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: chiserver.CorrelationTransport(nil)}
+	ctx := chiserver.ContextWithCorrID(context.Background(), "corr-456")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if received != "corr-456" {
+		t.Errorf("Expected downstream to receive 'corr-456', got '%s'", received)
+	}
+	if req.Header.Get(chiserver.CorrelationIDHeader) != "" {
+		t.Error("Expected caller's request to be left unmodified")
+	}
+}
+
+// TestNewCorrelatingClient_LogsRetries tests that downstream retries are counted in the request log
+func TestNewCorrelatingClient_LogsRetries(t *testing.T) {
+	var calls atomic.Int32
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer downstream.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	client := chiserver.NewCorrelatingClient(2)
+
+	handler := chiserver.RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("Expected downstream call to succeed, got: %v", err)
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected retried call to succeed with 200, got %d", w.Code)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 downstream calls, got %d", calls.Load())
+	}
+	if !strings.Contains(buf.String(), "downstream_retries=1") {
+		t.Errorf("Expected log to contain downstream_retries=1, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,16 @@
+package chiserver
+
+import "time"
+
+// Clock tells the time. Middlewares measuring durations accept one so tests
+// can substitute a fake clock and assert exact values.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the Clock backed by time.Now.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,218 @@
+package chiserver
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressMinSize is the smallest response body Compress will gzip.
+// Smaller bodies are not worth the overhead.
+const CompressMinSize = 1024
+
+// defaultCompressibleTypes is used when Compress is given no content types.
+var defaultCompressibleTypes = []string{
+	"text/html",
+	"text/plain",
+	"text/css",
+	"text/xml",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+}
+
+// Compress is a middleware that gzips responses for clients sending
+// Accept-Encoding: gzip. Only bodies of at least CompressMinSize bytes whose
+// Content-Type is listed are compressed; entries like "text/*" match a whole
+// family. With no types a default set of text and JSON types is used.
+//
+// Place it inside RequestLogger so the logged bytes reflect the compressed
+// size actually written to the client.
+func Compress(level int, contentTypes ...string) func(next http.Handler) http.Handler {
+	if len(contentTypes) == 0 {
+		contentTypes = defaultCompressibleTypes
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, level: level, types: contentTypes, status: http.StatusOK}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// acceptsGzip reports whether the client accepts gzip encoded responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		return q > 0
+	}
+	return false
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response qualifies for compression, then streams through gzip or as-is.
+type compressWriter struct {
+	http.ResponseWriter
+	level int
+	types []string
+
+	status      int
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	gz          *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		// Informational responses such as 103 Early Hints go out right
+		// away and leave the final status open.
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wroteHeader = true
+	if cw.decided {
+		return cw.write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= CompressMinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(len(cw.buf) >= CompressMinSize)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over for protocol upgrades such as
+// websockets, whose handshakes carry Accept-Encoding: gzip too.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		cw.decided, cw.wroteHeader = true, true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the headers and buffered body, compressing when large is true
+// and the response is eligible.
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if large && cw.compressible() {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gz, err := gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+		if err != nil {
+			return err
+		}
+		cw.gz = gz
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.write(buf)
+	return err
+}
+
+func (cw *compressWriter) write(p []byte) (int, error) {
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// compressible reports whether status, encoding and content type allow gzip.
+func (cw *compressWriter) compressible() bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, _ := strings.Cut(h.Get("Content-Type"), ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range cw.types {
+		if family, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, family+"/") {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// finish flushes whatever is still buffered and closes the gzip stream.
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			return
+		}
+		cw.decide(len(cw.buf) >= CompressMinSize)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+	}
+}
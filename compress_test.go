@@ -0,0 +1,175 @@
+package chiserver_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestCompress_GzipsLargeJSON tests that large listed responses are gzipped and logged at compressed size
+func TestCompress_GzipsLargeJSON(t *testing.T) {
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logBuf, nil))
+
+	payload := strings.Repeat(`{"name":"item"},`, 500)
+	handler := chiserver.RequestLogger(logger)(chiserver.Compress(gzip.BestSpeed)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload))
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+
+	compressedSize := w.Body.Len()
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected valid gzip body, got: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != payload {
+		t.Error("Expected decompressed body to match the original payload")
+	}
+
+	var entry struct {
+		Bytes int `json:"bytes"`
+	}
+	if err := json.Unmarshal(logBuf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log line: %v", err)
+	}
+	if entry.Bytes != compressedSize {
+		t.Errorf("Expected logged bytes %d to equal compressed size %d", entry.Bytes, compressedSize)
+	}
+}
+
+// TestCompress_SkipsIneligibleResponses tests small bodies, unlisted types and clients without gzip
+func TestCompress_SkipsIneligibleResponses(t *testing.T) {
+	large := strings.Repeat("a", 4096)
+
+	for name, tc := range map[string]struct {
+		contentType    string
+		body           string
+		acceptEncoding string
+	}{
+		"small body":       {"application/json", `{"ok":true}`, "gzip"},
+		"unlisted type":    {"image/png", large, "gzip"},
+		"no gzip accepted": {"application/json", large, ""},
+		"gzip refused":     {"application/json", large, "gzip;q=0"},
+	} {
+		handler := chiserver.Compress(gzip.DefaultCompression, "application/json")(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write([]byte(tc.body))
+			}),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if enc := w.Header().Get("Content-Encoding"); enc != "" {
+			t.Errorf("%s: expected no compression, got %q", name, enc)
+		}
+		if w.Body.String() != tc.body {
+			t.Errorf("%s: expected body to pass through unchanged", name)
+		}
+	}
+}
+
+// TestCompress_InformationalResponse tests that a 1xx response is sent through and the final status
+// and compression still apply
+func TestCompress_InformationalResponse(t *testing.T) {
+	payload := strings.Repeat(`{"name":"item"},`, 128)
+	srv := httptest.NewServer(chiserver.Compress(gzip.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(payload))
+	})))
+	defer srv.Close()
+
+	var hints int
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints++
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if hints != 1 {
+		t.Errorf("Expected one 103 response, got %d", hints)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected a gzipped 201, got %d with encoding %q", resp.StatusCode, resp.Header.Get("Content-Encoding"))
+	}
+}
+
+// TestCompress_Hijack tests that websocket-style upgrades can hijack the connection
+func TestCompress_Hijack(t *testing.T) {
+	srv := httptest.NewServer(chiserver.Compress(gzip.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("Expected the writer to implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("Expected hijack to succeed, got: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: test\r\nAccept-Encoding: gzip\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Expected an upgrade response, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,79 @@
+package chiserver
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// MaxConcurrent is a middleware that lets at most n requests run at once.
+// Excess requests queue until a slot frees up; requests whose context ends
+// while queued get 503 Service Unavailable. The time spent queued is added to
+// the request log as queue_wait_ms.
+func MaxConcurrent(n int) func(next http.Handler) http.Handler {
+	return NewConcurrencyLimiter(n, 0).Handler
+}
+
+// ConcurrencyLimiter caps the number of requests running at once using a
+// semaphore channel.
+type ConcurrencyLimiter struct {
+	sem  chan struct{}
+	wait time.Duration
+}
+
+// NewConcurrencyLimiter returns a limiter letting at most n requests run at
+// once. Excess requests queue for up to wait before getting 503 Service
+// Unavailable; a zero wait queues until the request context ends and a
+// negative one rejects them immediately.
+func NewConcurrencyLimiter(n int, wait time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{sem: make(chan struct{}, n), wait: wait}
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.sem)
+}
+
+// Handler is the limiting middleware. The time spent queued is added to the
+// request log as queue_wait_ms.
+func (l *ConcurrencyLimiter) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if !l.acquire(r) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		defer func() { <-l.sem }()
+
+		AddLogAttrs(r.Context(), slog.Int64("queue_wait_ms", time.Since(start).Milliseconds()))
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// acquire takes a slot, waiting as configured, and reports whether it got one.
+func (l *ConcurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+	if l.wait < 0 {
+		return false
+	}
+
+	var timeout <-chan time.Time
+	if l.wait > 0 {
+		timer := time.NewTimer(l.wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
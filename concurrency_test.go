@@ -0,0 +1,169 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent log writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestMaxConcurrent_LogsQueueWait tests that a queued request logs a positive queue_wait_ms
+func TestMaxConcurrent_LogsQueueWait(t *testing.T) {
+	var buf syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := chiserver.RequestLogger(logger)(chiserver.MaxConcurrent(1)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/first" {
+				close(started)
+				<-release
+			}
+			w.WriteHeader(http.StatusOK)
+		}),
+	))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/first", nil))
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/second", nil))
+	}()
+
+	// Keep the slot busy so /second has to queue
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Path        string `json:"path"`
+			QueueWaitMS int64  `json:"queue_wait_ms"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse log line %q: %v", line, err)
+		}
+		if entry.Path == "/second" {
+			if entry.QueueWaitMS <= 0 {
+				t.Errorf("Expected positive queue_wait_ms for queued request, got %d", entry.QueueWaitMS)
+			}
+			return
+		}
+	}
+	t.Fatalf("Expected a log line for /second, got: %s", buf.String())
+}
+
+// TestConcurrencyLimiter_WaitTimeout tests that queued requests get 503 after the wait and InFlight reports held slots
+func TestConcurrencyLimiter_WaitTimeout(t *testing.T) {
+	limiter := chiserver.NewConcurrencyLimiter(1, 50*time.Millisecond)
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			close(entered)
+			<-release
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/block", nil))
+	}()
+	<-entered
+
+	if got := limiter.InFlight(); got != 1 {
+		t.Errorf("Expected 1 request in flight, got %d", got)
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after waiting, got %d", w.Code)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond || waited > time.Second {
+		t.Errorf("Expected to wait about 50ms, waited %s", waited)
+	}
+
+	close(release)
+	<-done
+	if got := limiter.InFlight(); got != 0 {
+		t.Errorf("Expected no requests in flight, got %d", got)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the slot is free, got %d", w.Code)
+	}
+}
+
+// TestServer_MaxConcurrentRequests tests that the Config field installs a rejecting limiter
+func TestServer_MaxConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+		MaxConcurrentRequests: 1,
+		MaxConcurrentWait:     -1,
+	}, func(r chi.Router) {
+		r.Get("/block", func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		})
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/block", nil))
+	}()
+	<-entered
+
+	if got := server.ConcurrencyLimiter().InFlight(); got != 1 {
+		t.Errorf("Expected 1 request in flight, got %d", got)
+	}
+	w := httptest.NewRecorder()
+	server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected immediate 503 at capacity, got %d", w.Code)
+	}
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,75 @@
+package chiserver
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// drainLogInterval is how often Run logs the connections still open while
+// shutting down.
+const drainLogInterval = time.Second
+
+// connTracker records the state of the server's open connections, fed by
+// http.Server.ConnState.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+// track is an http.Server.ConnState hook.
+func (t *connTracker) track(c net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(t.conns, c)
+	default:
+		if t.conns == nil {
+			t.conns = make(map[net.Conn]http.ConnState)
+		}
+		t.conns[c] = state
+	}
+}
+
+// open returns the number of connections not yet closed or hijacked.
+func (t *connTracker) open() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+// active returns the number of connections serving a request.
+func (t *connTracker) active() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, state := range t.conns {
+		if state == http.StateActive {
+			n++
+		}
+	}
+	return n
+}
+
+// logDrain logs the open connections every drainLogInterval until done is
+// closed, so slow drains are visible during shutdown.
+func (s *Server) logDrain(done <-chan struct{}) {
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.logger.Info("waiting for connections to drain",
+				slog.Int("connections", s.conns.open()),
+				slog.Int("active", s.conns.active()),
+			)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package chiserver
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// DefaultContentType is a middleware that sets the Content-Type response
+// header to contentType when the handler has not set one by the time it
+// writes the status or first body bytes, instead of letting net/http sniff it.
+func DefaultContentType(contentType string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&contentTypeWriter{ResponseWriter: w, contentType: contentType}, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// contentTypeWriter applies a fallback Content-Type before headers are sent.
+type contentTypeWriter struct {
+	http.ResponseWriter
+	contentType string
+	wroteHeader bool
+}
+
+func (cw *contentTypeWriter) WriteHeader(code int) {
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		// Informational responses leave the final response to come.
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if cw.Header().Get("Content-Type") == "" && code != http.StatusNoContent && code != http.StatusNotModified {
+			cw.Header().Set("Content-Type", cw.contentType)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *contentTypeWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *contentTypeWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over for protocol upgrades such as
+// websockets, which type-assert http.Hijacker.
+func (cw *contentTypeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		cw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *contentTypeWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
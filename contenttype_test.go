@@ -0,0 +1,112 @@
+package chiserver_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestDefaultContentType_AppliedWhenUnset tests that the default is applied to handlers that forget it
+func TestDefaultContentType_AppliedWhenUnset(t *testing.T) {
+	handler := chiserver.DefaultContentType("application/json")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json, got %q", ct)
+	}
+}
+
+// TestDefaultContentType_KeepsExplicitType tests that an explicit Content-Type wins
+func TestDefaultContentType_KeepsExplicitType(t *testing.T) {
+	handler := chiserver.DefaultContentType("application/json")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("id\n1\n"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected text/csv, got %q", ct)
+	}
+}
+
+// TestDefaultContentType_InformationalResponse tests that a 1xx response neither gets the default
+// nor keeps the final response from getting it
+func TestDefaultContentType_InformationalResponse(t *testing.T) {
+	srv := httptest.NewServer(chiserver.DefaultContentType("application/json")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Write([]byte(`{"id":1}`))
+	})))
+	defer srv.Close()
+
+	var hintTypes []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			hintTypes = append(hintTypes, header.Get("Content-Type"))
+			return nil
+		},
+	}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(hintTypes) != 1 || hintTypes[0] != "" {
+		t.Errorf("Expected one 1xx response without Content-Type, got %q", hintTypes)
+	}
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "application/json" {
+		t.Errorf("Expected 200 with application/json, got %d %q", resp.StatusCode, ct)
+	}
+}
+
+// TestDefaultContentType_Hijack tests that websocket-style upgrades can hijack the connection
+func TestDefaultContentType_Hijack(t *testing.T) {
+	srv := httptest.NewServer(chiserver.DefaultContentType("application/json")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("Expected the writer to implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("Expected hijack to succeed, got: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Expected an upgrade response, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected 101, got %d", resp.StatusCode)
+	}
+}
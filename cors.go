@@ -0,0 +1,102 @@
+package chiserver
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists origins allowed to make cross-origin requests.
+	// "*" allows any origin and entries such as "https://*.example.com"
+	// match any subdomain.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD and POST when empty.
+	AllowedMethods []string
+	// AllowedHeaders lists request headers clients may send.
+	AllowedHeaders []string
+	// ExposedHeaders lists response headers readable by the client.
+	ExposedHeaders []string
+	// AllowCredentials permits cookies and authorization headers.
+	AllowCredentials bool
+	// MaxAge is how long, in seconds, preflight results may be cached.
+	MaxAge int
+}
+
+// CORS is a middleware that applies the given cross-origin policy. Preflight
+// requests are answered directly with 204 No Content.
+func CORS(opts CORSOptions) func(next http.Handler) http.Handler {
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	allowAll := slices.Contains(opts.AllowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if origin == "" || !(allowAll || originAllowed(opts.AllowedOrigins, origin)) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAll && !opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// originAllowed matches origin against exact and single-wildcard patterns.
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if prefix, suffix, ok := strings.Cut(pattern, "*"); ok {
+			if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
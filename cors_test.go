@@ -0,0 +1,93 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestCORS_Preflight tests that preflight requests are answered with 204 and CORS headers
+func TestCORS_Preflight(t *testing.T) {
+	handler := chiserver.CORS(chiserver.CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodDelete},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected preflight not to reach the handler")
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/items", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Methods":     "GET, DELETE",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("Expected %s %q, got %q", header, value, got)
+		}
+	}
+}
+
+// TestCORS_OriginMatching tests exact, wildcard and rejected origins on simple requests
+func TestCORS_OriginMatching(t *testing.T) {
+	handler := chiserver.CORS(chiserver.CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com":     true,
+		"https://tenant.example.org":  true,
+		"https://evil.com":            false,
+		"https://example.org.evil.io": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected request from %s to be served, got %d", origin, w.Code)
+		}
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if allowed && got != origin {
+			t.Errorf("Expected origin %s to be allowed, got %q", origin, got)
+		}
+		if !allowed && got != "" {
+			t.Errorf("Expected origin %s to be rejected, got %q", origin, got)
+		}
+	}
+}
+
+// TestCORS_AllowAll tests that "*" allows any origin without credentials
+func TestCORS_AllowAll(t *testing.T) {
+	handler := chiserver.CORS(chiserver.CORSOptions{AllowedOrigins: []string{"*"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://anything.test")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected wildcard origin, got %q", got)
+	}
+}
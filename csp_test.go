@@ -0,0 +1,35 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestCSPNonce_HeaderMatchesContext tests that the header nonce matches the context and changes per request
+func TestCSPNonce_HeaderMatchesContext(t *testing.T) {
+	var nonce string
+	handler := chiserver.CSPNonce()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = chiserver.CSPNonceFromContext(r.Context())
+	}))
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if nonce == "" {
+			t.Fatal("Expected nonce in request context")
+		}
+		expected := "script-src 'nonce-" + nonce + "'"
+		if got := w.Header().Get("Content-Security-Policy"); got != expected {
+			t.Errorf("Expected CSP header %q, got %q", expected, got)
+		}
+		if seen[nonce] {
+			t.Errorf("Expected a fresh nonce per request, got %q twice", nonce)
+		}
+		seen[nonce] = true
+	}
+}
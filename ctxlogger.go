@@ -0,0 +1,39 @@
+package chiserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// Key to use when setting the request-scoped logger.
+type ctxKeyLogger int
+
+const loggerKey ctxKeyLogger = 0
+
+// ContextLogger is a middleware that stores logger, tagged with the request's
+// correlation ID, in the request context for handlers to retrieve with
+// LoggerFromContext. It must run after CorrelationID.
+func ContextLogger(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			l := logger.With(slog.String("correlation_id", GetCorrID(r.Context())))
+			next.ServeHTTP(w, r.WithContext(ContextWithLogger(r.Context(), l)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ContextWithLogger returns a copy of ctx carrying logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// LoggerFromContext returns the logger stored by ContextLogger or
+// ContextWithLogger, falling back to slog.Default().
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
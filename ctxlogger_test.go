@@ -0,0 +1,55 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestServer_EnableContextLogger tests that handler logs carry the correlation ID without boilerplate
+func TestServer_EnableContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := chiserver.Config{
+		Logger:              slog.New(slog.NewTextHandler(&buf, nil)),
+		EnableContextLogger: true,
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+			chiserver.LoggerFromContext(r.Context()).Info("listing orders")
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "ctx-logger-1")
+	server.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "listing orders") {
+			if !strings.Contains(line, "correlation_id=ctx-logger-1") {
+				t.Errorf("Expected handler log to carry the correlation ID, got %q", line)
+			}
+			return
+		}
+	}
+	t.Errorf("Expected handler log line, got %q", buf.String())
+}
+
+// TestLoggerFromContext_Default tests that slog.Default is returned without a stored logger
+func TestLoggerFromContext_Default(t *testing.T) {
+	if got := chiserver.LoggerFromContext(context.Background()); got != slog.Default() {
+		t.Errorf("Expected slog.Default(), got %v", got)
+	}
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if got := chiserver.LoggerFromContext(chiserver.ContextWithLogger(context.Background(), logger)); got != logger {
+		t.Errorf("Expected stored logger, got %v", got)
+	}
+}
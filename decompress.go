@@ -0,0 +1,69 @@
+package chiserver
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecompressRequest is a middleware that transparently decodes request bodies
+// sent with Content-Encoding gzip or deflate, so handlers read plain data.
+// The Content-Encoding and Content-Length headers are removed. Decoded bodies
+// are limited to maxBytes to guard against decompression bombs: reading past
+// the limit fails with *http.MaxBytesError and the client gets 413, as with
+// MaxBodyBytes. Malformed bodies get 400 and other encodings 415 Unsupported
+// Media Type.
+func DecompressRequest(maxBytes int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var (
+				decoded io.ReadCloser
+				err     error
+			)
+			switch encoding {
+			case "gzip", "x-gzip":
+				decoded, err = gzip.NewReader(r.Body)
+			case "deflate":
+				decoded, err = zlib.NewReader(r.Body)
+			default:
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			tw := &tooLargeWriter{ResponseWriter: w}
+			r.Body = &decompressedBody{
+				ReadCloser: tw.watch(http.MaxBytesReader(w, decoded, maxBytes)),
+				raw:        r.Body,
+			}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(tw, r)
+			tw.finish()
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// decompressedBody closes the decoder and the underlying request body.
+type decompressedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.raw.Close()
+}
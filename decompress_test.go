@@ -0,0 +1,143 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// newDecompressHandler returns a handler echoing the request body, answering read errors with 400
+func newDecompressHandler(maxBytes int64) http.Handler {
+	return chiserver.DecompressRequest(maxBytes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Content-Encoding", r.Header.Get("Content-Encoding"))
+		w.Write(body)
+	}))
+}
+
+// gzipBytes returns data gzip compressed
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+// TestDecompressRequest_Gzip tests that gzip bodies are decoded and the encoding header removed
+func TestDecompressRequest_Gzip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipBytes(t, []byte(`{"name":"gopher"}`))))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	newDecompressHandler(1024).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w.Body.String() != `{"name":"gopher"}` {
+		t.Errorf("Expected decoded body, got %q", w.Body.String())
+	}
+	if enc := w.Header().Get("X-Content-Encoding"); enc != "" {
+		t.Errorf("Expected Content-Encoding to be removed, got %q", enc)
+	}
+}
+
+// TestDecompressRequest_Deflate tests that deflate bodies are decoded
+func TestDecompressRequest_Deflate(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Encoding", "deflate")
+	w := httptest.NewRecorder()
+	newDecompressHandler(1024).ServeHTTP(w, req)
+
+	if w.Body.String() != "hello" {
+		t.Errorf("Expected decoded body, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestDecompressRequest_Oversized tests that bodies decompressing past the limit surface
+// *http.MaxBytesError and are answered with 413 by the middleware
+func TestDecompressRequest_Oversized(t *testing.T) {
+	var readErr error
+	handler := chiserver.DecompressRequest(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+	bomb := gzipBytes(t, make([]byte, 1<<20))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Errorf("Expected *http.MaxBytesError, got: %v", readErr)
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a %d byte body inflating past the limit, got %d", len(bomb), w.Code)
+	}
+}
+
+// TestDecompressRequest_OversizedHandlerError tests that the 413 replaces the handler's own error response
+func TestDecompressRequest_OversizedHandlerError(t *testing.T) {
+	bomb := gzipBytes(t, make([]byte, 1<<20))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	newDecompressHandler(1024).ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a %d byte body inflating past the limit, got %d", len(bomb), w.Code)
+	}
+}
+
+// TestDecompressRequest_Plain tests that unencoded bodies pass through untouched
+func TestDecompressRequest_Plain(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", 2048)))
+	w := httptest.NewRecorder()
+	newDecompressHandler(1024).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.Len() != 2048 {
+		t.Errorf("Expected plain body to be untouched, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
+
+// TestDecompressRequest_InvalidEncodings tests malformed and unsupported encodings
+func TestDecompressRequest_InvalidEncodings(t *testing.T) {
+	tests := []struct {
+		encoding string
+		expected int
+	}{
+		{"gzip", http.StatusBadRequest},
+		{"br", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not compressed"))
+		req.Header.Set("Content-Encoding", tt.encoding)
+		w := httptest.NewRecorder()
+		newDecompressHandler(1024).ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("Content-Encoding %q: expected %d, got %d", tt.encoding, tt.expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package chiserver
+
+import (
+	"context"
+	"net/http"
+)
+
+// EnrichContext is a middleware that passes the request context through each
+// enricher in order and serves the request with the result, e.g. to store a
+// tenant parsed from the host or a logger tagged with the correlation ID for
+// handlers to retrieve. Registered after CorrelationID, enrichers can read
+// the correlation ID with GetCorrID.
+func EnrichContext(enrichers ...func(ctx context.Context, r *http.Request) context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			for _, enrich := range enrichers {
+				ctx = enrich(ctx, r)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,58 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+type ctxKeyTest int
+
+const (
+	tenantKey ctxKeyTest = iota
+	loggerKey
+)
+
+// TestServer_ContextEnrichers tests that enrichers run in order with the correlation ID available
+func TestServer_ContextEnrichers(t *testing.T) {
+	var logs bytes.Buffer
+	appLogger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	cfg := chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ContextEnrichers: []func(ctx context.Context, r *http.Request) context.Context{
+			func(ctx context.Context, r *http.Request) context.Context {
+				tenant, _, _ := strings.Cut(r.Host, ".")
+				return context.WithValue(ctx, tenantKey, tenant)
+			},
+			func(ctx context.Context, r *http.Request) context.Context {
+				logger := appLogger.With("correlation_id", chiserver.GetCorrID(ctx), "tenant", ctx.Value(tenantKey))
+				return context.WithValue(ctx, loggerKey, logger)
+			},
+		},
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+			r.Context().Value(loggerKey).(*slog.Logger).Info("listing orders")
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://acme.example.com/orders", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "enrich-test-1")
+	w := httptest.NewRecorder()
+	server.Router().ServeHTTP(w, req)
+
+	out := logs.String()
+	if !strings.Contains(out, "correlation_id=enrich-test-1") || !strings.Contains(out, "tenant=acme") {
+		t.Errorf("Expected handler log tagged with correlation ID and tenant, got %q", out)
+	}
+}
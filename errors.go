@@ -0,0 +1,70 @@
+package chiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// errorResponse is the JSON body of error responses written by this package.
+type errorResponse struct {
+	Error         string `json:"error"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Status        int    `json:"status"`
+}
+
+// WriteError writes a JSON error body carrying the request's correlation ID,
+// so clients can quote it when reporting the failure:
+//
+//	{"error":"user not found","correlation_id":"...","status":404}
+//
+// Recoverer and Wrap answer with the same body; use it in handlers, including
+// NotFoundHandler and MethodNotAllowedHandler, to keep error responses uniform.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Error:         message,
+		CorrelationID: GetCorrID(r.Context()),
+		Status:        status,
+	})
+}
+
+// HTTPError is an error with the status and message Wrap sends to the client.
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// HandlerFunc is an HTTP handler that returns an error instead of writing
+// error responses itself. Adapt it with Wrap.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Wrap adapts fn to an http.Handler. A returned *HTTPError (possibly wrapped)
+// is answered with its status and message; any other error with a generic
+// 500, keeping internal details from clients. Error bodies are JSON and carry
+// the correlation ID, like Recoverer's. The error itself is added to the
+// request log line as "error".
+//
+//	r.Method(http.MethodGet, "/users/{id}", chiserver.Wrap(getUser))
+func Wrap(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := fn(w, r)
+		if err == nil {
+			return
+		}
+		AddLogAttrs(r.Context(), slog.String("error", err.Error()))
+
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			WriteError(w, r, httpErr.Status, httpErr.Message)
+			return
+		}
+		WriteError(w, r, http.StatusInternalServerError, "internal server error")
+	})
+}
@@ -0,0 +1,140 @@
+package chiserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestErrorResponses_CarryCorrelationID tests that panics and returned errors both answer with the
+// request's correlation ID
+func TestErrorResponses_CarryCorrelationID(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	r := chi.NewRouter()
+	r.Use(chiserver.CorrelationID, chiserver.Recoverer(logger))
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	r.Method(http.MethodGet, "/error", chiserver.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database unreachable")
+	}))
+	r.Method(http.MethodGet, "/missing", chiserver.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("load user: %w", &chiserver.HTTPError{Status: http.StatusNotFound, Message: "user not found"})
+	}))
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"/panic", http.StatusInternalServerError, "internal server error"},
+		{"/error", http.StatusInternalServerError, "internal server error"},
+		{"/missing", http.StatusNotFound, "user not found"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(chiserver.CorrelationIDHeader, "support-ref-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.expectedStatus, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: expected JSON content type, got %q", tt.path, ct)
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: expected JSON body, got %q: %v", tt.path, w.Body.String(), err)
+		}
+		if body["error"] != tt.expectedError || body["correlation_id"] != "support-ref-1" || body["status"] != float64(tt.expectedStatus) {
+			t.Errorf("%s: expected error %q with correlation ID and status, got %v", tt.path, tt.expectedError, body)
+		}
+	}
+}
+
+// TestServer_NotFoundAndMethodNotAllowedHandlers tests that configured 404 and 405 handlers are used,
+// including inside mounted sub-routers
+func TestServer_NotFoundAndMethodNotAllowedHandlers(t *testing.T) {
+	jsonError := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			chiserver.WriteError(w, r, status, http.StatusText(status))
+		}
+	}
+
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:                  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		NotFoundHandler:         jsonError(http.StatusNotFound),
+		MethodNotAllowedHandler: jsonError(http.StatusMethodNotAllowed),
+	}, func(r chi.Router) {
+		r.Route("/api", func(r chi.Router) {
+			r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	})
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound},
+		{http.MethodGet, "/api/nope", http.StatusNotFound},
+		{http.MethodPost, "/api/users", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(chiserver.CorrelationIDHeader, "support-ref-2")
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+
+		if w.Code != tt.expectedStatus {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.expectedStatus, w.Code)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["correlation_id"] != "support-ref-2" {
+			t.Errorf("%s %s: expected JSON body with correlation ID, got %q", tt.method, tt.path, w.Body.String())
+		}
+	}
+}
+
+// TestWriteError tests the exact body written, and that the correlation ID is omitted when unset
+func TestWriteError(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(chiserver.CorrelationID)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		chiserver.WriteError(w, r, http.StatusConflict, "already exists")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "support-ref-3")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON 409, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	expected := `{"error":"already exists","correlation_id":"support-ref-3","status":409}` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	chiserver.WriteError(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusBadRequest, "bad input")
+	expected = `{"error":"bad input","status":400}` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body without correlation ID %q, got %q", expected, w.Body.String())
+	}
+}
@@ -0,0 +1,96 @@
+package chiserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Key to use when setting the external URL.
+type ctxKeyExternalURL int
+
+const externalURLKey ctxKeyExternalURL = 0
+
+// ResolveExternalURL is a middleware that reconstructs the URL the client
+// used to reach the service and makes it available through ExternalURL.
+// X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Port are honored only
+// when the immediate peer is within one of trustedProxies; otherwise the
+// request's own scheme and Host are used.
+func ResolveExternalURL(trustedProxies ...netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			u := &url.URL{
+				Scheme:   "http",
+				Host:     r.Host,
+				Path:     r.URL.Path,
+				RawPath:  r.URL.RawPath,
+				RawQuery: r.URL.RawQuery,
+			}
+			if r.TLS != nil {
+				u.Scheme = "https"
+			}
+
+			if peerTrusted(r, trustedProxies) {
+				if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+					u.Scheme = proto
+				}
+				if host := firstHeaderValue(r, "X-Forwarded-Host"); host != "" {
+					u.Host = host
+				}
+				if port := firstHeaderValue(r, "X-Forwarded-Port"); port != "" {
+					hostname := u.Hostname()
+					if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+						u.Host = hostname
+						if strings.Contains(hostname, ":") {
+							u.Host = "[" + hostname + "]"
+						}
+					} else {
+						u.Host = net.JoinHostPort(hostname, port)
+					}
+				}
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), externalURLKey, u))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ExternalURL returns a copy of the external request URL resolved by
+// ResolveExternalURL, or nil when the middleware did not run.
+func ExternalURL(ctx context.Context) *url.URL {
+	u, ok := ctx.Value(externalURLKey).(*url.URL)
+	if !ok {
+		return nil
+	}
+	c := *u
+	return &c
+}
+
+// peerTrusted reports whether the request's immediate peer is in prefixes.
+// The peer address may be a bare IP or carry a port.
+func peerTrusted(r *http.Request, prefixes []netip.Prefix) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+	addr, ok := parseIP(peerAddr(r))
+	if !ok {
+		return false
+	}
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstHeaderValue returns the first comma-separated value of header.
+func firstHeaderValue(r *http.Request, header string) string {
+	v, _, _ := strings.Cut(r.Header.Get(header), ",")
+	return strings.TrimSpace(v)
+}
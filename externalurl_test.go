@@ -0,0 +1,91 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// resolveExternalURL runs req through ResolveExternalURL and returns the resolved URL
+func resolveExternalURL(req *http.Request) *url.URL {
+	var got *url.URL
+	handler := chiserver.ResolveExternalURL(netip.MustParsePrefix("10.0.0.0/8"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = chiserver.ExternalURL(r.Context())
+		}),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+// TestResolveExternalURL_ForwardedHeaders tests that trusted forwarded headers build the external URL
+func TestResolveExternalURL_ForwardedHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://internal:8080/items?page=2", nil)
+	req.RemoteAddr = "10.1.2.3:5555"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	req.Header.Set("X-Forwarded-Port", "8443")
+
+	got := resolveExternalURL(req)
+	if got == nil || got.String() != "https://api.example.com:8443/items?page=2" {
+		t.Errorf("Expected https://api.example.com:8443/items?page=2, got %v", got)
+	}
+}
+
+// TestResolveExternalURL_Fallback tests that the request's own URL is used without trusted headers
+func TestResolveExternalURL_Fallback(t *testing.T) {
+	plain := httptest.NewRequest(http.MethodGet, "http://internal:8080/items", nil)
+	plain.RemoteAddr = "10.1.2.3:5555"
+
+	spoofed := httptest.NewRequest(http.MethodGet, "http://internal:8080/items", nil)
+	spoofed.RemoteAddr = "203.0.113.7:5555"
+	spoofed.Header.Set("X-Forwarded-Proto", "https")
+	spoofed.Header.Set("X-Forwarded-Host", "evil.example.com")
+
+	for name, req := range map[string]*http.Request{"no headers": plain, "untrusted peer": spoofed} {
+		got := resolveExternalURL(req)
+		if got == nil || got.String() != "http://internal:8080/items" {
+			t.Errorf("%s: expected http://internal:8080/items, got %v", name, got)
+		}
+	}
+}
+
+// TestResolveExternalURL_AfterRealIP tests that the trusted proxy is still recognized once RealIP replaced RemoteAddr
+func TestResolveExternalURL_AfterRealIP(t *testing.T) {
+	trusted := netip.MustParsePrefix("10.0.0.0/8")
+
+	var got *url.URL
+	handler := chiserver.RealIP(trusted)(chiserver.ResolveExternalURL(trusted)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = chiserver.ExternalURL(r.Context())
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/x", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil || got.String() != "https://api.example.com/x" {
+		t.Errorf("Expected https://api.example.com/x, got %v", got)
+	}
+}
+
+// TestResolveExternalURL_BareRemoteAddr tests that a RemoteAddr without a port is still checked against the trusted proxies
+func TestResolveExternalURL_BareRemoteAddr(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://internal:8080/items", nil)
+	req.RemoteAddr = "10.1.2.3"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+
+	got := resolveExternalURL(req)
+	if got == nil || got.String() != "https://api.example.com/items" {
+		t.Errorf("Expected https://api.example.com/items, got %v", got)
+	}
+}
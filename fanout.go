@@ -0,0 +1,43 @@
+package chiserver
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// FanOut runs tasks concurrently and waits for them to finish. Each task gets
+// a context derived from ctx that is cancelled as soon as any task fails or
+// ctx itself is done, so passing the request context stops all siblings when
+// the client goes away. It returns the errors of every failed task joined
+// with errors.Join, leaving out the context.Canceled errors of siblings that
+// only stopped because another task failed.
+//
+//	err := chiserver.FanOut(r.Context(),
+//		func(ctx context.Context) error { return loadUser(ctx, id) },
+//		func(ctx context.Context) error { return loadOrders(ctx, id) },
+//	)
+func FanOut(ctx context.Context, tasks ...func(ctx context.Context) error) error {
+	g, gctx := errgroup.WithContext(ctx)
+	errs := make([]error, len(tasks))
+	for i, task := range tasks {
+		g.Go(func() error {
+			errs[i] = task(gctx)
+			return errs[i]
+		})
+	}
+	first := g.Wait()
+	if first == nil {
+		return nil
+	}
+
+	if ctx.Err() == nil {
+		// gctx was cancelled by a failing task, not by the caller.
+		errs = slices.DeleteFunc(errs, func(err error) bool {
+			return errors.Is(err, context.Canceled) && !errors.Is(err, first)
+		})
+	}
+	return errors.Join(errs...)
+}
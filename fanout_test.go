@@ -0,0 +1,104 @@
+package chiserver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestFanOut_CancelsSiblingsOnError tests that a failing task cancels the others and its error is returned
+func TestFanOut_CancelsSiblingsOnError(t *testing.T) {
+	errFailed := errors.New("upstream failed")
+	var cancelled atomic.Int32
+
+	waitForCancel := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			cancelled.Add(1)
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+
+	start := time.Now()
+	err := chiserver.FanOut(context.Background(),
+		waitForCancel,
+		func(context.Context) error { return errFailed },
+		waitForCancel,
+	)
+
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected task error to be returned, got: %v", err)
+	}
+	if cancelled.Load() != 2 {
+		t.Errorf("Expected 2 sibling tasks to be cancelled, got %d", cancelled.Load())
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected siblings to stop promptly after the failure")
+	}
+}
+
+// TestFanOut_AllSucceed tests that FanOut returns nil when every task succeeds
+func TestFanOut_AllSucceed(t *testing.T) {
+	var ran atomic.Int32
+	task := func(context.Context) error {
+		ran.Add(1)
+		return nil
+	}
+
+	if err := chiserver.FanOut(context.Background(), task, task, task); err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+	if ran.Load() != 3 {
+		t.Errorf("Expected 3 tasks to run, got %d", ran.Load())
+	}
+}
+
+// TestFanOut_JoinsErrors tests that the errors of every failed task are returned, without the
+// cancellation errors of siblings stopped by the failure
+func TestFanOut_JoinsErrors(t *testing.T) {
+	errUsers := errors.New("users failed")
+	errOrders := errors.New("orders failed")
+	failed := make(chan struct{})
+
+	err := chiserver.FanOut(context.Background(),
+		func(context.Context) error {
+			defer close(failed)
+			return errUsers
+		},
+		func(ctx context.Context) error {
+			<-failed
+			return errOrders
+		},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	)
+
+	if !errors.Is(err, errUsers) || !errors.Is(err, errOrders) {
+		t.Errorf("Expected both task errors, got: %v", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled sibling to be left out, got: %v", err)
+	}
+}
+
+// TestFanOut_CallerCancelled tests that cancellation by the caller is reported
+func TestFanOut_CallerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := chiserver.FanOut(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package chiserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// GRPCTimeoutHeader carries the client deadline of gRPC and gRPC-Gateway calls.
+const GRPCTimeoutHeader = "Grpc-Timeout"
+
+// grpcTimeoutUnits maps Grpc-Timeout unit suffixes to durations.
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// ParseGRPCTimeout parses a Grpc-Timeout value: up to 8 digits followed by a
+// unit of H, M, S, m (milliseconds), u (microseconds) or n (nanoseconds).
+func ParseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+	unit, ok := grpcTimeoutUnits[v[len(v)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// GRPCTimeout is a middleware that applies the deadline sent in the
+// Grpc-Timeout header to the request context, falling back to defaultTimeout
+// when the header is missing or malformed (zero means no deadline). The
+// parsed timeout is logged as "grpc_timeout". Handlers are expected to honour
+// the context; if the deadline passes before they write a response, the
+// client gets 504 Gateway Timeout.
+func GRPCTimeout(defaultTimeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := ParseGRPCTimeout(r.Header.Get(GRPCTimeoutHeader))
+			if !ok {
+				timeout = defaultTimeout
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			AddLogAttrs(ctx, slog.Duration("grpc_timeout", timeout))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if ww.Status() == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(w, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
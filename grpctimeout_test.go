@@ -0,0 +1,91 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestParseGRPCTimeout tests parsing of Grpc-Timeout values
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"100m", 100 * time.Millisecond, true},
+		{"5S", 5 * time.Second, true},
+		{"2H", 2 * time.Hour, true},
+		{"3M", 3 * time.Minute, true},
+		{"250u", 250 * time.Microsecond, true},
+		{"", 0, false},
+		{"5", 0, false},
+		{"5s", 0, false},
+		{"-5S", 0, false},
+		{"123456789S", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := chiserver.ParseGRPCTimeout(tt.value)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("ParseGRPCTimeout(%q) = %s, %v; expected %s, %v", tt.value, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// TestGRPCTimeout_InvalidUsesDefault tests that a malformed header falls back to the default timeout
+func TestGRPCTimeout_InvalidUsesDefault(t *testing.T) {
+	var remaining time.Duration
+	handler := chiserver.GRPCTimeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			t.Error("Expected request context to carry the default deadline")
+			return
+		}
+		remaining = time.Until(deadline)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(chiserver.GRPCTimeoutHeader, "soon")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if remaining < 59*time.Second || remaining > time.Minute {
+		t.Errorf("Expected about one minute until the deadline, got %s", remaining)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+// TestGRPCTimeout_DeadlineExceeded tests that an expired deadline is answered with 504 and logged
+func TestGRPCTimeout_DeadlineExceeded(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger)(chiserver.GRPCTimeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(chiserver.GRPCTimeoutHeader, "20m")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status 504, got %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), "grpc_timeout=20ms") {
+		t.Errorf("Expected log to contain grpc_timeout=20ms, got: %s", buf.String())
+	}
+}
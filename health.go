@@ -0,0 +1,205 @@
+package chiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Health statuses reported by HealthChecker.
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+	// HealthStatusDraining is reported for the "shutdown" check while the
+	// server drains before shutting down.
+	HealthStatusDraining = "draining"
+)
+
+// HealthReport is the result of running a HealthChecker's checks.
+type HealthReport struct {
+	Status string `json:"status"`
+	// Checks maps each check name to "ok" or its error message.
+	Checks map[string]string `json:"checks"`
+}
+
+// HealthChecker runs a set of named checks, e.g. for Kubernetes liveness and
+// readiness probes.
+type HealthChecker struct {
+	mu       sync.RWMutex
+	checks   map[string]func(ctx context.Context) error
+	draining atomic.Bool
+}
+
+// NewHealthChecker returns a HealthChecker without checks, which reports healthy.
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{checks: make(map[string]func(ctx context.Context) error)}
+}
+
+// Register adds a check under name, replacing any check with the same name.
+func (h *HealthChecker) Register(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetDraining marks the checker unavailable regardless of its checks, e.g.
+// so load balancers stop routing traffic to a server about to shut down.
+func (h *HealthChecker) SetDraining(draining bool) {
+	h.draining.Store(draining)
+}
+
+// Check runs all checks concurrently and reports the aggregate status.
+func (h *HealthChecker) Check(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := make(map[string]func(ctx context.Context) error, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	report := HealthReport{Status: HealthStatusOK, Checks: make(map[string]string, len(checks)+1)}
+	if h.draining.Load() {
+		report.Status = HealthStatusUnavailable
+		report.Checks["shutdown"] = HealthStatusDraining
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := HealthStatusOK
+			if err := check(ctx); err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result != HealthStatusOK {
+				report.Status = HealthStatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Handler serves the HealthReport with 200 when every check passes and 503
+// otherwise. The body is JSON unless the Accept header prefers text/plain, in
+// which case a "status=ok" line is followed by one "name=result" line per
+// check, sorted by name. Backslashes and line breaks in names and results
+// are escaped as in Go strings, so they cannot forge extra lines.
+func (h *HealthChecker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Check(r.Context())
+
+		plain := prefersPlainText(r)
+		if plain {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Add("Vary", "Accept")
+		if report.Status != HealthStatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if !plain {
+			json.NewEncoder(w).Encode(report)
+			return
+		}
+		fmt.Fprintf(w, "status=%s\n", report.Status)
+		for _, name := range slices.Sorted(maps.Keys(report.Checks)) {
+			fmt.Fprintf(w, "%s=%s\n", plainTextEscaper.Replace(name), plainTextEscaper.Replace(report.Checks[name]))
+		}
+	})
+}
+
+// plainTextEscaper escapes backslashes and line breaks in plain-text health
+// lines.
+var plainTextEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, "\r", `\r`)
+
+// prefersPlainText reports whether the Accept header ranks text/plain above
+// application/json. Each type takes the quality of its most specific
+// matching range, so text/* and */* count too. JSON wins ties and requests
+// without an Accept header.
+func prefersPlainText(r *http.Request) bool {
+	textQ, jsonQ := acceptQuality{}, acceptQuality{}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		textQ.match(mediaType, "text", "plain", q)
+		jsonQ.match(mediaType, "application", "json", q)
+	}
+	return textQ.q > jsonQ.q
+}
+
+// acceptQuality tracks the quality an Accept header gives one media type,
+// taken from the most specific range matching it.
+type acceptQuality struct {
+	q           float64
+	specificity int
+}
+
+// match records q if mediaType matches typ/subtype at least as specifically
+// as the ranges seen so far.
+func (a *acceptQuality) match(mediaType, typ, subtype string, q float64) {
+	var specificity int
+	switch mediaType {
+	case typ + "/" + subtype:
+		specificity = 3
+	case typ + "/*":
+		specificity = 2
+	case "*/*":
+		specificity = 1
+	default:
+		return
+	}
+	switch {
+	case specificity > a.specificity:
+		a.q, a.specificity = q, specificity
+	case specificity == a.specificity:
+		a.q = max(a.q, q)
+	}
+}
+
+// Liveness returns the checker backing HealthHandler.
+func (s *Server) Liveness() *HealthChecker {
+	return s.liveness
+}
+
+// Readiness returns the checker backing ReadyHandler.
+func (s *Server) Readiness() *HealthChecker {
+	return s.readiness
+}
+
+// HealthHandler serves the liveness checks.
+func (s *Server) HealthHandler() http.Handler {
+	return s.liveness.Handler()
+}
+
+// ReadyHandler serves the readiness checks.
+func (s *Server) ReadyHandler() http.Handler {
+	return s.readiness.Handler()
+}
@@ -0,0 +1,236 @@
+package chiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestHealthChecker_AllPassing tests that passing checks produce 200 and an ok report
+func TestHealthChecker_AllPassing(t *testing.T) {
+	checker := chiserver.NewHealthChecker()
+	checker.Register("db", func(context.Context) error { return nil })
+	checker.Register("cache", func(context.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var report chiserver.HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON report, got %q: %v", w.Body.String(), err)
+	}
+	if report.Status != chiserver.HealthStatusOK || len(report.Checks) != 2 {
+		t.Errorf("Expected ok report with 2 checks, got %+v", report)
+	}
+}
+
+// TestHealthChecker_FailingCheck tests that a failing check produces 503 and lists the failure
+func TestHealthChecker_FailingCheck(t *testing.T) {
+	checker := chiserver.NewHealthChecker()
+	checker.Register("db", func(context.Context) error { return errors.New("connection refused") })
+	checker.Register("cache", func(context.Context) error { return nil })
+
+	w := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var report chiserver.HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON report, got %q: %v", w.Body.String(), err)
+	}
+	if report.Status != chiserver.HealthStatusUnavailable {
+		t.Errorf("Expected unavailable status, got %q", report.Status)
+	}
+	if report.Checks["db"] != "connection refused" || report.Checks["cache"] != chiserver.HealthStatusOK {
+		t.Errorf("Expected failing db and passing cache, got %v", report.Checks)
+	}
+}
+
+// TestHealthChecker_PlainText tests that Accept: text/plain produces one line per check
+func TestHealthChecker_PlainText(t *testing.T) {
+	checker := chiserver.NewHealthChecker()
+	checker.Register("db", func(context.Context) error { return errors.New("connection refused") })
+	checker.Register("cache", func(context.Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain Content-Type, got %q", ct)
+	}
+	expected := "status=unavailable\ncache=ok\ndb=connection refused\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+// TestHealthChecker_PlainTextEscapesNewlines tests that check names and errors cannot forge extra lines
+func TestHealthChecker_PlainTextEscapesNewlines(t *testing.T) {
+	checker := chiserver.NewHealthChecker()
+	checker.Register("db\nfake", func(context.Context) error { return errors.New("down\r\nstatus=ok") })
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(w, req)
+
+	expected := "status=unavailable\n" + `db\nfake=down\r\nstatus=ok` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, w.Body.String())
+	}
+}
+
+// TestHealthChecker_ContentNegotiation tests which Accept headers select JSON or plain text
+func TestHealthChecker_ContentNegotiation(t *testing.T) {
+	checker := chiserver.NewHealthChecker()
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"application/json", "application/json"},
+		{"*/*", "application/json"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"application/json;q=0.5, text/plain", "text/plain; charset=utf-8"},
+		{"text/plain;q=0.5, application/json", "application/json"},
+		{"text/plain;charset=utf-8;q=0.1, application/json", "application/json"},
+		{"application/json;q=0.5, text/plain;charset=utf-8;q=0.9", "text/plain; charset=utf-8"},
+		{"text/*", "text/plain; charset=utf-8"},
+		{"text/*;q=0.2, */*;q=0.5", "application/json"},
+		{"application/json;q=0.5, */*;q=0.8", "text/plain; charset=utf-8"},
+		{"text/plain;q=0, */*", "application/json"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		checker.Handler().ServeHTTP(w, req)
+
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("Accept %q: expected Content-Type %q, got %q", tt.accept, tt.contentType, ct)
+		}
+	}
+}
+
+// TestServer_HealthPaths tests that the probes are mounted at the configured paths
+func TestServer_HealthPaths(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:       "127.0.0.1:0",
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		HealthPath: "/healthz",
+		ReadyPath:  "/readyz",
+	}, func(r chi.Router) {})
+	server.Readiness().Register("warmup", func(context.Context) error { return errors.New("warming up") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	<-server.Started()
+
+	for path, expected := range map[string]int{
+		"/healthz": http.StatusOK,
+		"/readyz":  http.StatusServiceUnavailable,
+	} {
+		resp, err := http.Get("http://" + server.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("Expected request to %s to succeed, got: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("Expected %s to return %d, got %d", path, expected, resp.StatusCode)
+		}
+	}
+
+	cancel()
+	<-errCh
+}
+
+// TestServer_PreShutdownDelay tests that readiness fails while the server keeps serving during the drain delay
+func TestServer_PreShutdownDelay(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:             "127.0.0.1:0",
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ReadyPath:        "/readyz",
+		PreShutdownDelay: 500 * time.Millisecond,
+	}, func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	<-server.Started()
+	base := "http://" + server.Addr().String()
+
+	cancel()
+
+	// Without keep-alives no idle connection can hold up Shutdown.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) int {
+		resp, err := client.Get(base + path)
+		if err != nil {
+			t.Fatalf("Expected request to %s to succeed during drain, got: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Readiness flips as soon as Run observes the cancellation.
+	deadline := time.Now().Add(time.Second)
+	for get("/readyz") != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected /readyz to return 503 during drain")
+		}
+		runtime.Gosched()
+	}
+	if code := get("/ping"); code != http.StatusOK {
+		t.Errorf("Expected /ping to return 200 during drain, got %d", code)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server did not shut down after the drain delay")
+	}
+}
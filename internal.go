@@ -0,0 +1,57 @@
+package chiserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+)
+
+// Key to use when setting the internal traffic flag.
+type ctxKeyInternal int
+
+const internalKey ctxKeyInternal = 0
+
+// InternalTraffic is a middleware that flags requests whose client IP falls
+// within one of cidrs as internal. The flag is readable with IsInternal and
+// added to the request log line as "internal". The client IP is the one
+// resolved by the RealIP/ClientIP middlewares, falling back to RemoteAddr.
+// It panics if a CIDR cannot be parsed.
+func InternalTraffic(cidrs []string) func(next http.Handler) http.Handler {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("chiserver: invalid internal CIDR %q: %v", cidr, err))
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			internal := false
+			if ip, err := netip.ParseAddr(clientIP(r)); err == nil {
+				ip = ip.Unmap()
+				for _, prefix := range prefixes {
+					if prefix.Contains(ip) {
+						internal = true
+						break
+					}
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), internalKey, internal)
+			AddLogAttrs(ctx, slog.Bool("internal", internal))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// IsInternal reports whether the request came from an internal network, as
+// classified by InternalTraffic. It returns false when the middleware did not run.
+func IsInternal(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalKey).(bool)
+	return internal
+}
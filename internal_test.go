@@ -0,0 +1,52 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestInternalTraffic_ClassifiesClientIP tests that internal and external client IPs are told apart
+func TestInternalTraffic_ClassifiesClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expected   bool
+	}{
+		{"internal", "10.1.2.3:5000", true},
+		{"external", "203.0.113.7:5000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			var got bool
+			handler := chiserver.RequestLogger(logger)(
+				chiserver.InternalTraffic([]string{"10.0.0.0/8", "192.168.0.0/16"})(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						got = chiserver.IsInternal(r.Context())
+					}),
+				),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("Expected IsInternal to be %v, got %v", tt.expected, got)
+			}
+			if field := fmt.Sprintf("internal=%t", tt.expected); !strings.Contains(buf.String(), field) {
+				t.Errorf("Expected log to contain %s, got: %s", field, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package chiserver
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// WithErrorDedup collapses identical error lines: after a request with a 5xx
+// status is logged, further requests with the same method, path and status
+// within window are not logged individually. When the window ends, the last
+// of them is logged once with repeated=N, the number of suppressed requests.
+// The access log tee of WithAccessLogWriter still receives every request.
+func WithErrorDedup(window time.Duration) LoggerOption {
+	return func(o *loggerOptions) {
+		o.dedup = &logDedup{window: window, entries: make(map[dedupKey]*dedupEntry)}
+	}
+}
+
+// dedupKey identifies identical error lines.
+type dedupKey struct {
+	method string
+	path   string
+	status int
+}
+
+// dedupEntry holds the requests suppressed within the current window.
+type dedupEntry struct {
+	repeated int
+	level    slog.Level
+	attrs    []slog.Attr
+}
+
+// logDedup tracks the error lines logged within the current window.
+type logDedup struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[dedupKey]*dedupEntry
+}
+
+// suppress reports whether the line should be skipped because an identical
+// one was logged within the window. The first line of a window is let
+// through and schedules the repeated=N summary on logger.
+func (d *logDedup) suppress(logger *slog.Logger, key dedupKey, level slog.Level, attrs []slog.Attr) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, ok := d.entries[key]; ok {
+		entry.repeated++
+		entry.level = level
+		entry.attrs = attrs
+		return true
+	}
+
+	d.entries[key] = &dedupEntry{}
+	time.AfterFunc(d.window, func() {
+		d.mu.Lock()
+		entry := d.entries[key]
+		delete(d.entries, key)
+		d.mu.Unlock()
+
+		if entry.repeated > 0 {
+			logger.LogAttrs(context.Background(), entry.level, "request", append(entry.attrs, slog.Int("repeated", entry.repeated))...)
+		}
+	})
+	return false
+}
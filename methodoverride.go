@@ -0,0 +1,36 @@
+package chiserver
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MethodOverrideHeader is the header clients use to tunnel another method.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// overridableMethods are the methods a request may be rewritten to.
+var overridableMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// MethodOverride is a middleware that rewrites r.Method from the
+// X-HTTP-Method-Override header, for clients behind proxies that only allow
+// GET and POST. Only requests using one of allowedFrom (POST by default) are
+// rewritten, and only to PUT, PATCH or DELETE. It must run before routing,
+// i.e. be registered with Use on the root router.
+func MethodOverride(allowedFrom ...string) func(next http.Handler) http.Handler {
+	if len(allowedFrom) == 0 {
+		allowedFrom = []string{http.MethodPost}
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			override := strings.ToUpper(r.Header.Get(MethodOverrideHeader))
+			if override != "" && slices.Contains(allowedFrom, r.Method) && slices.Contains(overridableMethods, override) {
+				r.Method = override
+				r.Header.Del(MethodOverrideHeader)
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
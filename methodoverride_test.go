@@ -0,0 +1,51 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// newOverrideRouter returns a router reporting which method handler ran
+func newOverrideRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(chiserver.MethodOverride())
+	r.Get("/items/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get"))
+	})
+	r.Post("/items/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("post"))
+	})
+	r.Delete("/items/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete"))
+	})
+	return r
+}
+
+// TestMethodOverride_PostToDelete tests that POST with an override header routes to DELETE
+func TestMethodOverride_PostToDelete(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/items/1", nil)
+	req.Header.Set(chiserver.MethodOverrideHeader, "DELETE")
+	w := httptest.NewRecorder()
+	newOverrideRouter().ServeHTTP(w, req)
+
+	if w.Body.String() != "delete" {
+		t.Errorf("Expected DELETE handler to run, got %q", w.Body.String())
+	}
+}
+
+// TestMethodOverride_IgnoredFromGet tests that GET requests cannot be overridden
+func TestMethodOverride_IgnoredFromGet(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(chiserver.MethodOverrideHeader, "DELETE")
+	w := httptest.NewRecorder()
+	newOverrideRouter().ServeHTTP(w, req)
+
+	if w.Body.String() != "get" {
+		t.Errorf("Expected GET handler to run, got %q", w.Body.String())
+	}
+}
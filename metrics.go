@@ -0,0 +1,99 @@
+package chiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+	// DefaultMetricsPath is where the metrics endpoint is mounted by default.
+	DefaultMetricsPath = "/metrics"
+	// DefaultMetricsPushJob is the Pushgateway job name used when none is configured.
+	DefaultMetricsPushJob = "chi_server"
+)
+
+// unmatchedRoute labels requests that did not match any route, keeping
+// arbitrary 404 paths out of the label set.
+const unmatchedRoute = "unmatched"
+
+// Metrics is a middleware recording RED metrics in reg: a request counter by
+// method, route and status and a latency histogram by method and route. Routes
+// are labelled with the chi route pattern (e.g. "/users/{id}") rather than the
+// raw path to bound cardinality, so it must be installed with Use on a chi router.
+func Metrics(reg prometheus.Registerer) func(next http.Handler) http.Handler {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	reg.MustRegister(requests, duration)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			requests.WithLabelValues(r.Method, route, strconv.Itoa(ww.Status())).Inc()
+			duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// newMetricsRegistry returns a registry with the standard Go and process collectors.
+func newMetricsRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// MetricsRegistry returns the server's Prometheus registry, so applications
+// can register their own collectors next to the request metrics.
+func (s *Server) MetricsRegistry() *prometheus.Registry {
+	return s.metrics
+}
+
+// MetricsHandler serves the server's metrics in the Prometheus exposition format.
+func (s *Server) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{})
+}
+
+// PushMetricsOnShutdown registers a shutdown hook pushing a final snapshot of
+// the server's metrics to the Pushgateway at url under job, so the last
+// values survive the process once scraping stops. The hook runs after the
+// HTTP server has drained, bounded by the shutdown context.
+func (s *Server) PushMetricsOnShutdown(url, job string) {
+	if job == "" {
+		job = DefaultMetricsPushJob
+	}
+	pusher := push.New(url, job).Gatherer(s.metrics)
+	s.OnShutdown(func(ctx context.Context) error {
+		if err := pusher.PushContext(ctx); err != nil {
+			return fmt.Errorf("push metrics: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package chiserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestMetrics_UsesRoutePattern tests that requests are counted by route pattern rather than raw path
+func TestMetrics_UsesRoutePattern(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	r := chi.NewRouter()
+	r.Use(chiserver.Metrics(reg))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP http_requests_total Total number of HTTP requests by method, route and status.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/users/{id}",status="200"} 3
+http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "http_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(reg, "http_request_duration_seconds"); n != 2 {
+		t.Errorf("Expected 2 latency series, got %d", n)
+	}
+}
+
+// TestServer_MetricsPushURL tests that metrics are pushed to the Pushgateway during shutdown
+func TestServer_MetricsPushURL(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushes []string
+		body   string
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pushes = append(pushes, r.Method+" "+r.URL.Path)
+		body = string(data)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	cfg := chiserver.Config{
+		Addr:           "127.0.0.1:0",
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		EnableMetrics:  true,
+		MetricsPushURL: gateway.URL,
+		MetricsPushJob: "orders",
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := server.Run(ctx); err != nil {
+		t.Fatalf("Expected clean shutdown, got: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushes) != 1 || pushes[0] != "PUT /metrics/job/orders" {
+		t.Fatalf("Expected one push to /metrics/job/orders, got %v", pushes)
+	}
+	if body == "" {
+		t.Error("Expected pushed metrics payload, got empty body")
+	}
+}
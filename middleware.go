@@ -2,43 +2,263 @@ package chiserver
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"strings"
+	"sync"
+	"unicode"
 
+	"io"
 	"log/slog"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Key to use when setting the request ID.
 type ctxKeyCorrelationID int
 
 // CorrelationIDKey is the key that holds the unique request ID in a request context.
+// Its value is stable, so code outside the HTTP path (background jobs, database
+// drivers) can read the ID with GetCorrID and set it with ContextWithCorrID.
 const CorrelationIDKey ctxKeyCorrelationID = 0
 
+// Key to use when setting the header name the correlation ID arrived with.
+type ctxKeyCorrelationHeader int
+
+const correlationHeaderKey ctxKeyCorrelationHeader = 0
+
 // CorrelationIDHeader is the name of the HTTP Header which contains the request id.
-// Exported so that it can be changed by developers
+// It is the default for middlewares without their own CorrelationIDConfig.HeaderName
+// and servers without Config.CorrelationIDHeader.
+//
+// Deprecated: changing it at runtime races with requests in flight. Set
+// CorrelationIDConfig.HeaderName or Config.CorrelationIDHeader instead.
 var CorrelationIDHeader = "X-Correlation-ID"
 
+// CorrelationIDGenerator creates a correlation ID when the request carries none.
+// Defaults to random UUIDs; exported so that it can be changed by developers
+var CorrelationIDGenerator = func() string {
+	return uuid.New().String()
+}
+
+// MaxCorrelationIDLength is the longest incoming correlation ID accepted by
+// ValidCorrelationID.
+const MaxCorrelationIDLength = 128
+
+// CorrelationIDValidator checks client-supplied correlation IDs. When it returns
+// false the middleware generates a fresh ID instead of propagating the incoming
+// one. Set to nil to trust incoming IDs verbatim.
+var CorrelationIDValidator = ValidCorrelationID
+
+// ValidCorrelationID is the default validator. It rejects IDs longer than
+// MaxCorrelationIDLength bytes or containing control characters, which guards
+// logs against injection.
+func ValidCorrelationID(id string) bool {
+	if len(id) > MaxCorrelationIDLength {
+		return false
+	}
+	for _, r := range id {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // CorrelationID is a chi middleware that sets or propagates a correlation ID
 func CorrelationID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		correlationID := r.Header.Get(CorrelationIDHeader)
-		if correlationID == "" {
-			correlationID = uuid.New().String()
+		serveWithCorrID(w, r, next, CorrelationIDHeader, incomingCorrID(r, CorrelationIDHeader))
+	})
+}
+
+// CorrelationIDConfig configures CorrelationIDWithConfig.
+type CorrelationIDConfig struct {
+	// HeaderName is the request and response header carrying the ID.
+	// Defaults to CorrelationIDHeader.
+	HeaderName string
+	// IncomingHeaders lists the request headers checked for an incoming ID,
+	// in order, e.g. X-Request-ID and X-Amzn-Trace-Id for IDs set by
+	// different proxies. The first valid value wins. The response always
+	// uses HeaderName. Defaults to HeaderName alone.
+	IncomingHeaders []string
+	// UseTraceParent derives the correlation ID from the trace-id of a valid
+	// W3C traceparent header, so logs and traces share one identifier.
+	// Requests without one fall back to IncomingHeaders, HeaderName by
+	// default, or a new ID.
+	UseTraceParent bool
+}
+
+// CorrelationIDWithConfig is CorrelationID with the behavior adjusted by cfg.
+// Each middleware keeps its own settings, so servers in the same process can
+// use different header names.
+func CorrelationIDWithConfig(cfg CorrelationIDConfig) func(next http.Handler) http.Handler {
+	src := newCorrIDSource(cfg)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			serveWithCorrID(w, r, next, src.header, src.resolve(r))
 		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// corrIDSource is where a middleware configured by a CorrelationIDConfig
+// takes correlation IDs from.
+type corrIDSource struct {
+	header      string
+	incoming    []string
+	traceParent bool
+}
 
-		// Add it to the request context
-		ctx := context.WithValue(r.Context(), CorrelationIDKey, correlationID)
-		r = r.WithContext(ctx)
+// newCorrIDSource applies the defaults of cfg.
+func newCorrIDSource(cfg CorrelationIDConfig) corrIDSource {
+	header := cfg.HeaderName
+	if header == "" {
+		header = CorrelationIDHeader
+	}
+	incoming := cfg.IncomingHeaders
+	if len(incoming) == 0 {
+		incoming = []string{header}
+	}
+	return corrIDSource{header: header, incoming: incoming, traceParent: cfg.UseTraceParent}
+}
 
-		// Also add it to the response header
-		w.Header().Set(CorrelationIDHeader, correlationID)
+// resolve returns the correlation ID of r, or a new one.
+func (src corrIDSource) resolve(r *http.Request) string {
+	if src.traceParent {
+		if correlationID := traceParentID(r); correlationID != "" {
+			return correlationID
+		}
+	}
+	return incomingCorrID(r, src.incoming...)
+}
 
-		next.ServeHTTP(w, r)
-	})
+// traceParentID returns the hex trace-id of the request's traceparent header,
+// or "" when it is missing or invalid.
+func traceParentID(r *http.Request) string {
+	ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsRemote() || !sc.TraceID().IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// incomingCorrID returns the first valid correlation ID sent by the client in
+// headers, or a new one.
+func incomingCorrID(r *http.Request, headers ...string) string {
+	for _, header := range headers {
+		correlationID := r.Header.Get(header)
+		if correlationID == "" || (CorrelationIDValidator != nil && !CorrelationIDValidator(correlationID)) {
+			continue
+		}
+		return correlationID
+	}
+	return CorrelationIDGenerator()
+}
+
+// serveWithCorrID stores correlationID in the request context and the
+// response header before calling next.
+func serveWithCorrID(w http.ResponseWriter, r *http.Request, next http.Handler, header, correlationID string) {
+	// Add it to the request context, with the header InjectCorrID forwards it in
+	ctx := ContextWithCorrID(r.Context(), correlationID)
+	r = r.WithContext(context.WithValue(ctx, correlationHeaderKey, header))
+
+	// Also add it to the response header
+	w.Header().Set(header, correlationID)
+
+	next.ServeHTTP(w, r)
+}
+
+// activeCorrID tracks in-flight requests sharing a correlation ID.
+type activeCorrID struct {
+	requests int
+	dups     int
+}
+
+// UniqueCorrelationID is CorrelationID for clients that may reuse IDs by
+// mistake. When a request arrives with the ID of another in-flight request,
+// it gets the ID with a "-dupN" suffix instead, keeping the log lines of both
+// separable, and a warning is logged. At most maxActive distinct IDs are
+// tracked; beyond that, new IDs pass through unchecked.
+func UniqueCorrelationID(logger *slog.Logger, maxActive int) func(next http.Handler) http.Handler {
+	return UniqueCorrelationIDWithConfig(logger, maxActive, CorrelationIDConfig{})
+}
+
+// UniqueCorrelationIDWithConfig is UniqueCorrelationID with the header names
+// and traceparent handling set by cfg, as for CorrelationIDWithConfig.
+func UniqueCorrelationIDWithConfig(logger *slog.Logger, maxActive int, cfg CorrelationIDConfig) func(next http.Handler) http.Handler {
+	src := newCorrIDSource(cfg)
+	var (
+		mu     sync.Mutex
+		active = make(map[string]*activeCorrID)
+	)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			correlationID := src.resolve(r)
+			assigned := correlationID
+
+			mu.Lock()
+			entry, tracked := active[correlationID]
+			if !tracked && len(active) < maxActive {
+				entry = &activeCorrID{}
+				active[correlationID] = entry
+				tracked = true
+			}
+			if tracked {
+				if entry.requests > 0 {
+					entry.dups++
+					assigned = fmt.Sprintf("%s-dup%d", correlationID, entry.dups)
+				}
+				entry.requests++
+			}
+			mu.Unlock()
+
+			if tracked {
+				defer func() {
+					mu.Lock()
+					defer mu.Unlock()
+					if entry.requests--; entry.requests == 0 {
+						delete(active, correlationID)
+					}
+				}()
+			}
+
+			if assigned != correlationID {
+				logger.Warn("duplicate correlation ID",
+					slog.String("correlation_id", correlationID),
+					slog.String("assigned", assigned),
+				)
+			}
+
+			serveWithCorrID(w, r, next, src.header, assigned)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ContextWithCorrID returns a copy of ctx carrying the given correlation ID
+func ContextWithCorrID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CorrelationIDKey, id)
+}
+
+// corrIDHeader returns the header the correlation ID in ctx was received
+// with, or CorrelationIDHeader outside of a correlation ID middleware.
+func corrIDHeader(ctx context.Context) string {
+	if header, ok := ctx.Value(correlationHeaderKey).(string); ok {
+		return header
+	}
+	return CorrelationIDHeader
 }
 
 // GetCorrID extracts correlation ID from context
@@ -49,23 +269,302 @@ func GetCorrID(ctx context.Context) string {
 	return ""
 }
 
+// Key to use when setting the request log attributes.
+type ctxKeyLogAttrs int
+
+const logAttrsKey ctxKeyLogAttrs = 0
+
+// logAttrs collects attributes added during a request for its log line.
+type logAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// AddLogAttrs attaches attributes to the request log line emitted by
+// RequestLogger. Middlewares and handlers running inside RequestLogger can use
+// it to enrich the access log; outside of it the call is a no-op.
+func AddLogAttrs(ctx context.Context, attrs ...slog.Attr) {
+	la, ok := ctx.Value(logAttrsKey).(*logAttrs)
+	if !ok {
+		return
+	}
+	la.mu.Lock()
+	la.attrs = append(la.attrs, attrs...)
+	la.mu.Unlock()
+}
+
+// addLogCount adds n to the integer attribute key of the request log line,
+// creating it on first use, so repeated events are logged as one total.
+func addLogCount(ctx context.Context, key string, n int64) {
+	la, ok := ctx.Value(logAttrsKey).(*logAttrs)
+	if !ok {
+		return
+	}
+	la.mu.Lock()
+	defer la.mu.Unlock()
+	for i, attr := range la.attrs {
+		if attr.Key == key && attr.Value.Kind() == slog.KindInt64 {
+			la.attrs[i] = slog.Int64(key, attr.Value.Int64()+n)
+			return
+		}
+	}
+	la.attrs = append(la.attrs, slog.Int64(key, n))
+}
+
+// LoggerOption configures RequestLogger.
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	skipPaths    map[string]struct{}
+	skipPrefixes []string
+	level        func(status int) slog.Level
+	slow         time.Duration
+	accessLog    *slog.Logger
+	reqHeaders   []string
+	respHeaders  []string
+	clock        Clock
+	ecs          bool
+	dedup        *logDedup
+	sampleRate   float64
+	fields       []slog.Attr
+}
+
+// StatusLevel is the default mapping from response status to log level:
+// 5xx logs at Error, 4xx at Warn and everything else at Info.
+func StatusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithLevelFunc overrides how the response status maps to the log level.
+// Pass a function returning slog.LevelInfo to log every request at Info.
+func WithLevelFunc(fn func(status int) slog.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = fn
+	}
+}
+
+// WithSkipPaths disables logging for the given paths. Paths ending in "*" match
+// by prefix, so "/debug/*" skips everything under /debug/. Skipped requests are
+// still served.
+func WithSkipPaths(paths ...string) LoggerOption {
+	return func(o *loggerOptions) {
+		for _, p := range paths {
+			if prefix, ok := strings.CutSuffix(p, "*"); ok {
+				o.skipPrefixes = append(o.skipPrefixes, prefix)
+				continue
+			}
+			if o.skipPaths == nil {
+				o.skipPaths = make(map[string]struct{})
+			}
+			o.skipPaths[p] = struct{}{}
+		}
+	}
+}
+
+// WithSlowRequestThreshold flags requests taking longer than d: they are logged
+// at Warn level (or higher, if the status already warrants it) with slow=true.
+// A zero threshold disables the check.
+func WithSlowRequestThreshold(d time.Duration) LoggerOption {
+	return func(o *loggerOptions) {
+		o.slow = d
+	}
+}
+
+// WithAccessLogWriter tees every request record to w as JSON, in addition to
+// the main logger, e.g. for an append-only compliance file. Records are
+// written regardless of the main logger's level. Writes happen on the request
+// path, so wrap slow sinks in a BatchWriter:
+//
+//	bw := chiserver.NewBatchWriter(file, 100, time.Second)
+//	chiserver.RequestLogger(logger, chiserver.WithAccessLogWriter(bw))
+func WithAccessLogWriter(w io.Writer) LoggerOption {
+	return func(o *loggerOptions) {
+		o.accessLog = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+}
+
+// WithLoggedHeaders logs the listed request and response headers in the
+// "request_headers" and "response_headers" groups, e.g. while debugging an
+// integration. Only allow-listed names are logged, so secrets such as
+// Authorization stay out of the logs unless listed explicitly. Headers
+// missing from a message are omitted.
+func WithLoggedHeaders(reqHeaders, respHeaders []string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.reqHeaders = reqHeaders
+		o.respHeaders = respHeaders
+	}
+}
+
+// headerGroup returns the names present in h as a log group, or false if none are.
+func headerGroup(key string, h http.Header, names []string) (slog.Attr, bool) {
+	var attrs []any
+	for _, name := range names {
+		if values := h.Values(name); len(values) > 0 {
+			attrs = append(attrs, slog.String(http.CanonicalHeaderKey(name), strings.Join(values, ", ")))
+		}
+	}
+	if len(attrs) == 0 {
+		return slog.Attr{}, false
+	}
+	return slog.Group(key, attrs...), true
+}
+
+// WithECS names the request attributes after the Elastic Common Schema and
+// nests them in groups, so a JSON handler emits e.g. http.request.method,
+// url.path, client.ip and event.duration (in nanoseconds) that Kibana parses
+// natively. The correlation ID is logged as http.request.id. Attributes added
+// with AddLogAttrs keep their names.
+func WithECS() LoggerOption {
+	return func(o *loggerOptions) {
+		o.ecs = true
+	}
+}
+
+// ecsAttrs returns the request attributes in Elastic Common Schema layout.
+func ecsAttrs(r *http.Request, ww middleware.WrapResponseWriter, duration time.Duration) []slog.Attr {
+	return []slog.Attr{
+		slog.Group("http",
+			slog.Group("request",
+				slog.String("method", r.Method),
+				slog.String("id", GetCorrID(r.Context())),
+			),
+			slog.Group("response",
+				slog.Int("status_code", ww.Status()),
+				slog.Group("body", slog.Int("bytes", ww.BytesWritten())),
+			),
+		),
+		slog.Group("url", slog.String("path", r.URL.Path)),
+		slog.Group("client", slog.String("ip", middleware.GetClientIP(r.Context()))),
+		slog.Group("event", slog.Int64("duration", duration.Nanoseconds())),
+	}
+}
+
+// WithLogFields adds attrs to every request record, e.g. the service name
+// and environment so log queries can filter on them.
+func WithLogFields(attrs ...slog.Attr) LoggerOption {
+	return func(o *loggerOptions) {
+		o.fields = append(o.fields, attrs...)
+	}
+}
+
+// WithSampleRate logs only the given fraction of successful (1xx-3xx)
+// requests, while 4xx and 5xx responses and slow requests are always logged.
+// The decision hashes the correlation ID, so all services sharing an ID make
+// the same choice and a request is either logged everywhere or nowhere.
+// Requests without a correlation ID are sampled at random. The default rate
+// of 1 logs every request. The access log tee of WithAccessLogWriter is not
+// sampled.
+func WithSampleRate(rate float64) LoggerOption {
+	return func(o *loggerOptions) {
+		o.sampleRate = rate
+	}
+}
+
+// sampled reports whether a successful request falls within the sample rate.
+func (o *loggerOptions) sampled(ctx context.Context) bool {
+	if o.sampleRate >= 1 {
+		return true
+	}
+	id := GetCorrID(ctx)
+	if id == "" {
+		return rand.Float64() < o.sampleRate
+	}
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return float64(h.Sum64())/math.MaxUint64 < o.sampleRate
+}
+
+// WithClock makes RequestLogger measure durations with c instead of the
+// system clock, e.g. a fake clock in tests.
+func WithClock(c Clock) LoggerOption {
+	return func(o *loggerOptions) {
+		o.clock = c
+	}
+}
+
+// skip reports whether requests to path should not be logged.
+func (o *loggerOptions) skip(path string) bool {
+	if _, ok := o.skipPaths[path]; ok {
+		return true
+	}
+	for _, prefix := range o.skipPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // RequestLogger logs each HTTP request using slog.
-func RequestLogger(logger *slog.Logger) func(next http.Handler) http.Handler {
+func RequestLogger(logger *slog.Logger, opts ...LoggerOption) func(next http.Handler) http.Handler {
+	o := &loggerOptions{level: StatusLevel, clock: realClock{}, sampleRate: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
+			if o.skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := o.clock.Now()
+			la := &logAttrs{}
+			r = r.WithContext(context.WithValue(r.Context(), logAttrsKey, la))
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			next.ServeHTTP(ww, r)
+			duration := o.clock.Now().Sub(start)
 
-			logger.Info("request",
-				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
-				slog.Int("status", ww.Status()),
-				slog.Int("bytes", ww.BytesWritten()),
-				slog.String("remote", middleware.GetClientIP(r.Context())),
-				slog.String("correlation_id", GetCorrID(r.Context())),
-				slog.Duration("duration", time.Since(start)),
-			)
+			var attrs []slog.Attr
+			if o.ecs {
+				attrs = ecsAttrs(r, ww, duration)
+			} else {
+				attrs = []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", ww.Status()),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.String("remote", middleware.GetClientIP(r.Context())),
+					slog.String("correlation_id", GetCorrID(r.Context())),
+					slog.Duration("duration", duration),
+				}
+			}
+			attrs = append(attrs, o.fields...)
+			la.mu.Lock()
+			attrs = append(attrs, la.attrs...)
+			la.mu.Unlock()
+			if group, ok := headerGroup("request_headers", r.Header, o.reqHeaders); ok {
+				attrs = append(attrs, group)
+			}
+			if group, ok := headerGroup("response_headers", ww.Header(), o.respHeaders); ok {
+				attrs = append(attrs, group)
+			}
+
+			level := o.level(ww.Status())
+			slow := o.slow > 0 && duration > o.slow
+			if slow {
+				level = max(level, slog.LevelWarn)
+				attrs = append(attrs, slog.Bool("slow", true))
+			}
+
+			switch {
+			case ww.Status() < 400 && !slow && !o.sampled(r.Context()):
+			case ww.Status() >= 500 && o.dedup != nil &&
+				o.dedup.suppress(logger, dedupKey{r.Method, r.URL.Path, ww.Status()}, level, attrs):
+			default:
+				logger.LogAttrs(r.Context(), level, "request", attrs...)
+			}
+			if o.accessLog != nil {
+				o.accessLog.LogAttrs(r.Context(), level, "request", attrs...)
+			}
 		}
 		return http.HandlerFunc(fn)
 	}
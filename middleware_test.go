@@ -3,12 +3,20 @@ package chiserver_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/pmatteo/chi_server"
@@ -100,6 +108,217 @@ func TestCorrelationID_CustomHeader(t *testing.T) {
 	}
 }
 
+// TestServer_CorrelationIDHeader tests that servers in one process use their own header names
+func TestServer_CorrelationIDHeader(t *testing.T) {
+	for _, header := range []string{"X-Request-ID", "X-Trace-ID"} {
+		server := chiserver.NewServer(chiserver.Config{
+			Logger:              slog.New(slog.NewTextHandler(io.Discard, nil)),
+			CorrelationIDHeader: header,
+		}, func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header, "id-"+header)
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+
+		if got := w.Header().Get(header); got != "id-"+header {
+			t.Errorf("Expected %s to echo 'id-%s', got '%s'", header, header, got)
+		}
+		if got := w.Header().Get(chiserver.CorrelationIDHeader); got != "" {
+			t.Errorf("Expected no default header, got '%s'", got)
+		}
+	}
+}
+
+// TestCorrelationIDWithConfig_HeaderName tests that middlewares with different header names run concurrently
+// without touching the global header
+func TestCorrelationIDWithConfig_HeaderName(t *testing.T) {
+	var wg sync.WaitGroup
+	for _, header := range []string{"X-Request-ID", "X-Trace-ID"} {
+		handler := chiserver.CorrelationIDWithConfig(chiserver.CorrelationIDConfig{HeaderName: header})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/test", nil)
+				req.Header.Set(header, "id-"+header)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				if got := w.Header().Get(header); got != "id-"+header {
+					t.Errorf("Expected %s to echo 'id-%s', got '%s'", header, header, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if chiserver.CorrelationIDHeader != "X-Correlation-ID" {
+		t.Errorf("Expected global header to stay 'X-Correlation-ID', got '%s'", chiserver.CorrelationIDHeader)
+	}
+}
+
+// TestCorrelationIDWithConfig_IncomingHeaders tests that the first valid candidate header wins
+// and the response uses the canonical header
+func TestCorrelationIDWithConfig_IncomingHeaders(t *testing.T) {
+	handler := chiserver.CorrelationIDWithConfig(chiserver.CorrelationIDConfig{
+		IncomingHeaders: []string{"X-Correlation-ID", "X-Request-ID", "X-Amzn-Trace-Id"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chiserver.GetCorrID(r.Context())))
+	}))
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"first present", map[string]string{"X-Correlation-ID": "corr-1", "X-Request-ID": "req-1"}, "corr-1"},
+		{"fallback", map[string]string{"X-Amzn-Trace-Id": "Root=1-abc"}, "Root=1-abc"},
+		{"invalid skipped", map[string]string{"X-Correlation-ID": "bad\nid", "X-Request-ID": "req-2"}, "req-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected correlation ID %q, got %q", tt.expected, w.Body.String())
+			}
+			if got := w.Header().Get("X-Correlation-ID"); got != tt.expected {
+				t.Errorf("Expected canonical response header %q, got %q", tt.expected, got)
+			}
+			if w.Header().Get("X-Request-ID") != "" {
+				t.Error("Expected only the canonical header in the response")
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Body.Len() == 0 {
+		t.Error("Expected a generated ID without candidate headers")
+	}
+}
+
+// TestCorrelationID_CustomGenerator tests that a custom generator is used when no ID is provided
+func TestCorrelationID_CustomGenerator(t *testing.T) {
+	// Save original and restore after test
+	originalGenerator := chiserver.CorrelationIDGenerator
+	defer func() { chiserver.CorrelationIDGenerator = originalGenerator }()
+
+	chiserver.CorrelationIDGenerator = func() string { return "short-id" }
+
+	handler := chiserver.CorrelationID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if correlationID := chiserver.GetCorrID(r.Context()); correlationID != "short-id" {
+			t.Errorf("Expected generated ID short-id, got %s", correlationID)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if headerID := w.Header().Get(chiserver.CorrelationIDHeader); headerID != "short-id" {
+		t.Errorf("Expected short-id in header, got %s", headerID)
+	}
+}
+
+// TestCorrelationID_RejectsInvalidID tests that invalid incoming IDs are replaced with fresh ones
+func TestCorrelationID_RejectsInvalidID(t *testing.T) {
+	for name, incoming := range map[string]string{
+		"newline":  "abc\ninjected log line",
+		"too long": strings.Repeat("a", chiserver.MaxCorrelationIDLength+1),
+	} {
+		handler := chiserver.CorrelationID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(chiserver.CorrelationIDHeader, incoming)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		headerID := w.Header().Get(chiserver.CorrelationIDHeader)
+		if headerID == incoming {
+			t.Errorf("%s: expected invalid ID to be replaced", name)
+		}
+		if _, err := uuid.Parse(headerID); err != nil {
+			t.Errorf("%s: expected fresh UUID, got %q", name, headerID)
+		}
+	}
+}
+
+// TestCorrelationID_NilValidatorTrustsIncoming tests that disabling validation propagates IDs verbatim
+func TestCorrelationID_NilValidatorTrustsIncoming(t *testing.T) {
+	originalValidator := chiserver.CorrelationIDValidator
+	defer func() { chiserver.CorrelationIDValidator = originalValidator }()
+
+	chiserver.CorrelationIDValidator = nil
+	incoming := strings.Repeat("a", chiserver.MaxCorrelationIDLength+1)
+
+	handler := chiserver.CorrelationID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, incoming)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if headerID := w.Header().Get(chiserver.CorrelationIDHeader); headerID != incoming {
+		t.Errorf("Expected incoming ID to be propagated, got %q", headerID)
+	}
+}
+
+// TestCorrelationIDWithConfig_UseTraceParent tests that the traceparent trace-id is used when valid
+// and the usual header/UUID logic otherwise
+func TestCorrelationIDWithConfig_UseTraceParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		header      string
+		expected    string
+	}{
+		{"valid traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "corr-123", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"invalid traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "corr-123", "corr-123"},
+		{"missing traceparent", "", "corr-123", "corr-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			handler := chiserver.CorrelationIDWithConfig(chiserver.CorrelationIDConfig{UseTraceParent: true})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					ctxID = chiserver.GetCorrID(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			req.Header.Set(chiserver.CorrelationIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if ctxID != tt.expected {
+				t.Errorf("Expected context ID '%s', got '%s'", tt.expected, ctxID)
+			}
+			if got := w.Header().Get(chiserver.CorrelationIDHeader); got != tt.expected {
+				t.Errorf("Expected response header '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
 // TestGetCorrID_ReturnsEmptyForMissingID tests that GetCorrID returns empty string when no ID in context
 func TestGetCorrID_ReturnsEmptyForMissingID(t *testing.T) {
 	ctx := context.Background()
@@ -131,6 +350,16 @@ func TestGetCorrID_ReturnsCorrectID(t *testing.T) {
 	}
 }
 
+// TestContextWithCorrID_ReadableByGetCorrID tests that the helper stores the ID under the shared key
+func TestContextWithCorrID_ReadableByGetCorrID(t *testing.T) {
+	expectedID := "background-job-42"
+	ctx := chiserver.ContextWithCorrID(context.Background(), expectedID)
+
+	if correlationID := chiserver.GetCorrID(ctx); correlationID != expectedID {
+		t.Errorf("Expected %s, got %s", expectedID, correlationID)
+	}
+}
+
 // TestRequestLogger_LogsRequest tests that RequestLogger logs the request with correct fields
 func TestRequestLogger_LogsRequest(t *testing.T) {
 	var buf bytes.Buffer
@@ -228,6 +457,303 @@ func TestRequestLogger_CapturesBytesWritten(t *testing.T) {
 	}
 }
 
+// TestRequestLogger_SkipPaths tests that skipped paths are served but not logged
+func TestRequestLogger_SkipPaths(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := chiserver.RequestLogger(logger, chiserver.WithSkipPaths("/health", "/debug/*"))(testHandler)
+
+	for _, path := range []string{"/health", "/debug/pprof/heap", "/api/users"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %s to be served with 200, got %d", path, w.Code)
+		}
+	}
+
+	logOutput := buf.String()
+	if strings.Contains(logOutput, `"path":"/health"`) || strings.Contains(logOutput, `"path":"/debug/pprof/heap"`) {
+		t.Errorf("Expected skipped paths not to be logged, got: %s", logOutput)
+	}
+	if !strings.Contains(logOutput, `"path":"/api/users"`) {
+		t.Errorf("Expected /api/users to be logged, got: %s", logOutput)
+	}
+}
+
+// TestRequestLogger_LevelByStatus tests that the log level follows the response status
+func TestRequestLogger_LevelByStatus(t *testing.T) {
+	for status, level := range map[int]string{
+		http.StatusOK:                  "INFO",
+		http.StatusNotFound:            "WARN",
+		http.StatusInternalServerError: "ERROR",
+	} {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		middleware := chiserver.RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if !strings.Contains(buf.String(), `"level":"`+level+`"`) {
+			t.Errorf("Expected status %d to log at %s, got: %s", status, level, buf.String())
+		}
+	}
+}
+
+// TestRequestLogger_WithLevelFunc tests that the level mapping can be overridden
+func TestRequestLogger_WithLevelFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	allInfo := chiserver.WithLevelFunc(func(int) slog.Level { return slog.LevelInfo })
+	middleware := chiserver.RequestLogger(logger, allInfo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(buf.String(), `"level":"INFO"`) {
+		t.Errorf("Expected overridden level INFO, got: %s", buf.String())
+	}
+}
+
+// TestRequestLogger_SlowRequestThreshold tests that slow requests are flagged at WARN
+func TestRequestLogger_SlowRequestThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	middleware := chiserver.RequestLogger(logger, chiserver.WithSlowRequestThreshold(10*time.Millisecond))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	fastLog := buf.String()
+	buf.Reset()
+	middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	slowLog := buf.String()
+
+	if strings.Contains(fastLog, `"slow"`) || !strings.Contains(fastLog, `"level":"INFO"`) {
+		t.Errorf("Expected fast request to log at INFO without slow flag, got: %s", fastLog)
+	}
+	if !strings.Contains(slowLog, `"slow":true`) || !strings.Contains(slowLog, `"level":"WARN"`) {
+		t.Errorf("Expected slow request to log at WARN with slow=true, got: %s", slowLog)
+	}
+}
+
+// TestRequestLogger_WithAccessLogWriter tests that request records are teed to the secondary writer
+func TestRequestLogger_WithAccessLogWriter(t *testing.T) {
+	var main, access bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&main, nil))
+
+	handler := chiserver.CorrelationID(chiserver.RequestLogger(logger, chiserver.WithAccessLogWriter(&access))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "corr-789")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(main.String(), "correlation_id=corr-789") {
+		t.Errorf("Expected main log to contain the request, got: %s", main.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(access.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON access log record, got %q: %v", access.String(), err)
+	}
+	if record["correlation_id"] != "corr-789" || record["path"] != "/orders" || record["status"] != float64(http.StatusCreated) {
+		t.Errorf("Expected access log to record the same request, got: %v", record)
+	}
+}
+
+// TestUniqueCorrelationID_SuffixesConcurrentDuplicate tests that a second in-flight request
+// reusing a correlation ID gets a suffixed ID
+func TestUniqueCorrelationID_SuffixesConcurrentDuplicate(t *testing.T) {
+	var buf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	firstStarted := make(chan struct{})
+	release := make(chan struct{})
+	ids := make(chan string, 2)
+
+	handler := chiserver.UniqueCorrelationID(logger, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids <- chiserver.GetCorrID(r.Context())
+		if r.URL.Path == "/first" {
+			close(firstStarted)
+			<-release
+		}
+	}))
+
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(chiserver.CorrelationIDHeader, "same-id")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		send("/first")
+	}()
+	<-firstStarted
+
+	second := send("/second")
+	close(release)
+	<-done
+
+	if first := <-ids; first != "same-id" {
+		t.Errorf("Expected first request to keep 'same-id', got '%s'", first)
+	}
+	if got := <-ids; got != "same-id-dup1" {
+		t.Errorf("Expected second request to get 'same-id-dup1', got '%s'", got)
+	}
+	if got := second.Header().Get(chiserver.CorrelationIDHeader); got != "same-id-dup1" {
+		t.Errorf("Expected response header 'same-id-dup1', got '%s'", got)
+	}
+	if !strings.Contains(buf.String(), "duplicate correlation ID") {
+		t.Errorf("Expected a duplicate warning, got: %s", buf.String())
+	}
+
+	// Once both requests are done the ID is free again.
+	send("/third")
+	if got := <-ids; got != "same-id" {
+		t.Errorf("Expected ID to be reusable after completion, got '%s'", got)
+	}
+}
+
+// TestUniqueCorrelationIDWithConfig tests that the configured header is read and set
+func TestUniqueCorrelationIDWithConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := chiserver.UniqueCorrelationIDWithConfig(logger, 100, chiserver.CorrelationIDConfig{HeaderName: "X-Request-ID"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "unique-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-ID"); got != "unique-1" {
+		t.Errorf("Expected X-Request-ID 'unique-1', got '%s'", got)
+	}
+}
+
+// TestRequestLogger_WithLoggedHeaders tests that only allow-listed headers are logged
+func TestRequestLogger_WithLoggedHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger, chiserver.WithLoggedHeaders(
+		[]string{"user-agent", "X-Tenant"},
+		[]string{"Content-Type"},
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("User-Agent", "debug-client")
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record struct {
+		RequestHeaders  map[string]string `json:"request_headers"`
+		ResponseHeaders map[string]string `json:"response_headers"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record: %v", err)
+	}
+
+	expectedReq := map[string]string{"User-Agent": "debug-client"}
+	expectedResp := map[string]string{"Content-Type": "application/json"}
+	if !reflect.DeepEqual(record.RequestHeaders, expectedReq) {
+		t.Errorf("Expected request headers %v, got %v", expectedReq, record.RequestHeaders)
+	}
+	if !reflect.DeepEqual(record.ResponseHeaders, expectedResp) {
+		t.Errorf("Expected response headers %v, got %v", expectedResp, record.ResponseHeaders)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Expected unlisted headers to stay out of the log, got: %s", buf.String())
+	}
+}
+
+// TestRequestLogger_NoLoggedHeadersByDefault tests that no header fields are logged without the option
+func TestRequestLogger_NoLoggedHeadersByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("User-Agent", "debug-client")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if strings.Contains(buf.String(), "_headers") {
+		t.Errorf("Expected no header fields, got: %s", buf.String())
+	}
+}
+
+// fakeClock is a Clock that only moves when advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestRequestLogger_WithClock tests that the logged duration equals the simulated elapsed time
+func TestRequestLogger_WithClock(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	handler := chiserver.RequestLogger(logger, chiserver.WithClock(clock), chiserver.WithSlowRequestThreshold(time.Second))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clock.Advance(1500 * time.Millisecond)
+		}),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	var record struct {
+		Duration time.Duration `json:"duration"`
+		Slow     bool          `json:"slow"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record: %v", err)
+	}
+	if record.Duration != 1500*time.Millisecond {
+		t.Errorf("Expected duration 1.5s, got %s", record.Duration)
+	}
+	if !record.Slow {
+		t.Error("Expected request to be flagged slow against the fake clock")
+	}
+}
+
 // TestMiddlewareChain_Integration tests both middlewares working together
 func TestMiddlewareChain_Integration(t *testing.T) {
 	var buf bytes.Buffer
@@ -265,3 +791,195 @@ func TestMiddlewareChain_Integration(t *testing.T) {
 		t.Errorf("Expected response body to contain correlation ID %s", corrID)
 	}
 }
+
+// TestRequestLogger_WithECS tests that ECS-named nested fields replace the default ones
+func TestRequestLogger_WithECS(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	handler := chiserver.CorrelationID(chiserver.RequestLogger(logger, chiserver.WithECS(), chiserver.WithClock(clock))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clock.Advance(250 * time.Millisecond)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("created"))
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "ecs-test-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record struct {
+		HTTP struct {
+			Request struct {
+				Method string `json:"method"`
+				ID     string `json:"id"`
+			} `json:"request"`
+			Response struct {
+				StatusCode int `json:"status_code"`
+				Body       struct {
+					Bytes int `json:"bytes"`
+				} `json:"body"`
+			} `json:"response"`
+		} `json:"http"`
+		URL struct {
+			Path string `json:"path"`
+		} `json:"url"`
+		Event struct {
+			Duration int64 `json:"duration"`
+		} `json:"event"`
+		Status *int `json:"status"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record: %v", err)
+	}
+
+	if record.HTTP.Request.Method != http.MethodPost || record.HTTP.Request.ID != "ecs-test-1" {
+		t.Errorf("Expected http.request fields, got %+v", record.HTTP.Request)
+	}
+	if record.HTTP.Response.StatusCode != http.StatusCreated || record.HTTP.Response.Body.Bytes != len("created") {
+		t.Errorf("Expected http.response fields, got %+v", record.HTTP.Response)
+	}
+	if record.URL.Path != "/orders" {
+		t.Errorf("Expected url.path /orders, got %q", record.URL.Path)
+	}
+	if record.Event.Duration != int64(250*time.Millisecond) {
+		t.Errorf("Expected event.duration in nanoseconds, got %d", record.Event.Duration)
+	}
+	if record.Status != nil {
+		t.Errorf("Expected default field names to be replaced, got: %s", buf.String())
+	}
+}
+
+// TestRequestLogger_WithErrorDedup tests that identical 500s are collapsed into one line with a repeat count
+func TestRequestLogger_WithErrorDedup(t *testing.T) {
+	var buf syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger, chiserver.WithErrorDedup(100*time.Millisecond))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ok" {
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}),
+	)
+
+	for range 50 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("Expected the first error and both successes to be logged, got %d lines: %s", lines, buf.String())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected one collapsed line after the window, got %d lines: %s", len(lines), buf.String())
+	}
+	var record struct {
+		Path     string `json:"path"`
+		Status   int    `json:"status"`
+		Repeated int    `json:"repeated"`
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &record); err != nil {
+		t.Fatalf("Failed to parse log record: %v", err)
+	}
+	if record.Path != "/broken" || record.Status != http.StatusInternalServerError || record.Repeated != 49 {
+		t.Errorf("Expected collapsed /broken 500 line with repeated=49, got %+v", record)
+	}
+}
+
+// TestRequestLogger_WithSampleRate tests that successes are sampled by correlation ID while errors are always logged
+func TestRequestLogger_WithSampleRate(t *testing.T) {
+	newLogged := func() (http.Handler, *bytes.Buffer) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		handler := chiserver.CorrelationID(chiserver.RequestLogger(logger, chiserver.WithSampleRate(0.25))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/fail" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}),
+		))
+		return handler, &buf
+	}
+	send := func(h http.Handler, path, id string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(chiserver.CorrelationIDHeader, id)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	first, firstLogs := newLogged()
+	second, secondLogs := newLogged()
+	for i := range 400 {
+		id := fmt.Sprintf("sample-%d", i)
+		send(first, "/ok", id)
+		send(second, "/ok", id)
+	}
+
+	logged := strings.Count(firstLogs.String(), "\n")
+	if logged < 50 || logged > 150 {
+		t.Errorf("Expected roughly a quarter of 400 successes to be logged, got %d", logged)
+	}
+	loggedIDs := func(logs string) []string {
+		var ids []string
+		for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+			var record struct {
+				CorrelationID string `json:"correlation_id"`
+			}
+			json.Unmarshal([]byte(line), &record)
+			ids = append(ids, record.CorrelationID)
+		}
+		return ids
+	}
+	if !slices.Equal(loggedIDs(firstLogs.String()), loggedIDs(secondLogs.String())) {
+		t.Error("Expected the same correlation IDs to be sampled by independent loggers")
+	}
+
+	firstLogs.Reset()
+	for i := range 20 {
+		send(first, "/fail", fmt.Sprintf("fail-%d", i))
+	}
+	if logged := strings.Count(firstLogs.String(), "\n"); logged != 20 {
+		t.Errorf("Expected every error to be logged, got %d of 20", logged)
+	}
+}
+
+// TestRequestLogger_WithLogFields tests that static fields are added to every request record
+func TestRequestLogger_WithLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger, chiserver.WithLogFields(
+		slog.String("service", "orders"),
+		slog.String("env", "prod"),
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var record struct {
+			Path    string `json:"path"`
+			Service string `json:"service"`
+			Env     string `json:"env"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to parse log record: %v", err)
+		}
+		if record.Path != "/orders" || record.Service != "orders" || record.Env != "prod" {
+			t.Errorf("Expected static fields on every record, got %+v", record)
+		}
+	}
+}
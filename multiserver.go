@@ -0,0 +1,35 @@
+package chiserver
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// MultiServer runs several servers with a shared lifecycle.
+type MultiServer struct {
+	servers []*Server
+}
+
+// NewMultiServer groups the given servers so they can be started and stopped together.
+func NewMultiServer(servers ...*Server) *MultiServer {
+	return &MultiServer{servers: servers}
+}
+
+// Run starts all servers and shuts them down together when ctx is cancelled
+// or any of them fails. Errors from every server are joined.
+func (m *MultiServer) Run(ctx context.Context) error {
+	g, gctx := errgroup.WithContext(ctx)
+	errs := make([]error, len(m.servers))
+
+	for i, s := range m.servers {
+		g.Go(func() error {
+			errs[i] = s.Run(gctx)
+			return errs[i]
+		})
+	}
+
+	_ = g.Wait()
+	return errors.Join(errs...)
+}
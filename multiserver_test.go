@@ -0,0 +1,99 @@
+package chiserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestMultiServer_RunAndShutdown tests that all servers serve and stop together
+func TestMultiServer_RunAndShutdown(t *testing.T) {
+	newServer := func(body string) *chiserver.Server {
+		return chiserver.NewServer(chiserver.Config{
+			Addr:   "127.0.0.1:0",
+			Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}, func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(body))
+			})
+		})
+	}
+
+	public := newServer("public")
+	internal := newServer("internal")
+	multi := chiserver.NewMultiServer(public, internal)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- multi.Run(ctx)
+	}()
+
+	<-public.Started()
+	<-internal.Started()
+
+	for _, tc := range []struct {
+		server *chiserver.Server
+		body   string
+	}{
+		{public, "public"},
+		{internal, "internal"},
+	} {
+		resp, err := http.Get("http://" + tc.server.Addr().String() + "/")
+		if err != nil {
+			t.Fatalf("Expected request to succeed, got: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tc.body {
+			t.Errorf("Expected body %q, got %q", tc.body, body)
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got error: %v", err)
+		}
+	case <-time.After(6 * time.Second):
+		t.Fatal("Servers did not shutdown within expected time")
+	}
+
+	for _, s := range []*chiserver.Server{public, internal} {
+		if _, err := http.Get("http://" + s.Addr().String() + "/"); err == nil {
+			t.Errorf("Expected server at %s to be stopped", s.Addr())
+		}
+	}
+}
+
+// TestMultiServer_FailureStopsAll tests that one failing server shuts down the others
+func TestMultiServer_FailureStopsAll(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	good := chiserver.NewServer(chiserver.Config{Addr: "127.0.0.1:0", Logger: logger}, func(r chi.Router) {})
+	bad := chiserver.NewServer(chiserver.Config{Addr: "invalid:address:format", Logger: logger}, func(r chi.Router) {})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chiserver.NewMultiServer(good, bad).Run(context.Background())
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("Expected error from failing server, got nil")
+		}
+	case <-time.After(6 * time.Second):
+		t.Fatal("MultiServer did not stop after a server failed")
+	}
+}
@@ -0,0 +1,141 @@
+package chiserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LowercasePath is a middleware that lowercases the request path before
+// routing, so mixed-case paths like /Users match a /users route. Percent-encoded
+// octets are kept as sent, so encoded characters such as %2F or non-ASCII
+// letters are not altered. It must be registered with Use on the root router.
+func LowercasePath(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if lowered, changed := lowercaseEscapedPath(r.URL.EscapedPath()); changed {
+			setEscapedPath(r.URL, lowered)
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RedirectLowercasePath is like LowercasePath but answers GET and HEAD
+// requests for mixed-case paths with a 301 redirect to the lowercase form, so
+// clients and caches converge on the canonical URL. Other methods are
+// rewritten in place since redirects would lose their body.
+func RedirectLowercasePath(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		lowered, changed := lowercaseEscapedPath(r.URL.EscapedPath())
+		if !changed {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			// Collapse leading slashes so //Evil.com/ cannot turn into a
+			// protocol-relative redirect.
+			target := "/" + strings.TrimLeft(lowered, "/")
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		setEscapedPath(r.URL, lowered)
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// StripSlashes is a middleware that removes trailing slashes from the
+// request path before routing, so /users/ matches a /users route. The root
+// path is left alone. It must be registered with Use on the root router and
+// does not suit FileServer, whose directory URLs end in a slash.
+func StripSlashes(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if stripped, changed := stripTrailingSlashes(r.URL.EscapedPath()); changed {
+			setEscapedPath(r.URL, stripped)
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RedirectSlashes is like StripSlashes but answers GET and HEAD requests with
+// a 301 redirect to the path without trailing slashes. Other methods are
+// rewritten in place since redirects would lose their body.
+func RedirectSlashes(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		stripped, changed := stripTrailingSlashes(r.URL.EscapedPath())
+		if !changed {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			// Collapse leading slashes so //evil.com/ cannot turn into a
+			// protocol-relative redirect.
+			target := "/" + strings.TrimLeft(stripped, "/")
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		setEscapedPath(r.URL, stripped)
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// stripTrailingSlashes removes the trailing slashes of an escaped path other
+// than the root and reports whether anything changed.
+func stripTrailingSlashes(p string) (string, bool) {
+	stripped := strings.TrimRight(p, "/")
+	if stripped == "" {
+		stripped = "/"
+	}
+	return stripped, stripped != p
+}
+
+// lowercaseEscapedPath lowercases the ASCII letters of an escaped path outside
+// of %XX escapes and reports whether anything changed.
+func lowercaseEscapedPath(p string) (string, bool) {
+	var (
+		b       strings.Builder
+		changed bool
+	)
+	b.Grow(len(p))
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '%' && i+2 < len(p):
+			b.WriteString(p[i : i+3])
+			i += 2
+		case 'A' <= c && c <= 'Z':
+			b.WriteByte(c + 'a' - 'A')
+			changed = true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if !changed {
+		return p, false
+	}
+	return b.String(), true
+}
+
+// setEscapedPath replaces u's path with the escaped path p. Like
+// url.URL.setPath, RawPath is only kept when the default encoding of the
+// decoded path differs from p, so encoded characters like %2F survive routing
+// while chi still decodes URL parameters of ordinary paths.
+func setEscapedPath(u *url.URL, p string) {
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return
+	}
+	u.Path = decoded
+	u.RawPath = ""
+	if u.EscapedPath() != p {
+		u.RawPath = p
+	}
+}
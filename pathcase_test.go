@@ -0,0 +1,191 @@
+package chiserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// newPathCaseRouter returns a router with lowercase routes echoing the matched parameter
+func newPathCaseRouter(mw func(http.Handler) http.Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Use(mw)
+	r.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("created"))
+	})
+	r.Get("/files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chi.URLParam(r, "name")))
+	})
+	return r
+}
+
+// TestLowercasePath_MatchesLowercaseRoute tests that /Users is routed to /users
+func TestLowercasePath_MatchesLowercaseRoute(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.LowercasePath).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "users" {
+		t.Errorf("Expected /users handler to run, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestLowercasePath_PreservesPercentEncoding tests that percent-encoded octets are kept as sent
+func TestLowercasePath_PreservesPercentEncoding(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/Files/A%2FB%C3%9C", nil)
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.LowercasePath).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if got := w.Body.String(); got != "a%2Fb%C3%9C" {
+		t.Errorf("Expected escapes to be preserved, got %q", got)
+	}
+	if req.URL.Path != "/files/a/bÜ" {
+		t.Errorf("Expected decoded path /files/a/bÜ, got %q", req.URL.Path)
+	}
+}
+
+// TestLowercasePath_DecodesURLParams tests that URL params are decoded as for paths that need no rewrite
+func TestLowercasePath_DecodesURLParams(t *testing.T) {
+	for _, path := range []string{"/files/john%20doe", "/Files/john%20doe"} {
+		w := httptest.NewRecorder()
+		newPathCaseRouter(chiserver.LowercasePath).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "john doe" {
+			t.Errorf("%s: expected param %q, got %d %q", path, "john doe", w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestRedirectLowercasePath_RedirectsGet tests that GET requests are redirected to the lowercase path
+func TestRedirectLowercasePath_RedirectsGet(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/Users?Page=2", nil)
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectLowercasePath).ServeHTTP(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected 301, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/users?Page=2" {
+		t.Errorf("Expected Location /users?Page=2, got %q", loc)
+	}
+}
+
+// TestRedirectLowercasePath_SameOrigin tests that leading slashes cannot produce an off-site redirect
+func TestRedirectLowercasePath_SameOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "//EVIL.example/X", nil)
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectLowercasePath).ServeHTTP(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected 301, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/evil.example/x" {
+		t.Errorf("Expected a same-origin redirect to /evil.example/x, got %q", loc)
+	}
+}
+
+// TestRedirectLowercasePath_RewritesPost tests that non-GET requests are rewritten instead of redirected
+func TestRedirectLowercasePath_RewritesPost(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/USERS", nil)
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectLowercasePath).ServeHTTP(w, req)
+
+	if w.Body.String() != "created" {
+		t.Errorf("Expected POST /users handler to run, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestStripSlashes tests that trailing slashes are removed before routing, except for the root
+func TestStripSlashes(t *testing.T) {
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/users/", "users"},
+		{"/users//", "users"},
+		{"/files/a%2Fb/", "a%2Fb"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		newPathCaseRouter(chiserver.StripSlashes).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.expectedBody {
+			t.Errorf("%s: expected %q, got %d %q", tt.path, tt.expectedBody, w.Code, w.Body.String())
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Use(chiserver.StripSlashes)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the root path to be left alone, got %d", w.Code)
+	}
+}
+
+// TestStripSlashes_DecodesURLParams tests that URL params are decoded as for paths without a trailing slash
+func TestStripSlashes_DecodesURLParams(t *testing.T) {
+	for _, path := range []string{"/files/john%20doe", "/files/john%20doe/"} {
+		w := httptest.NewRecorder()
+		newPathCaseRouter(chiserver.StripSlashes).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "john doe" {
+			t.Errorf("%s: expected param %q, got %d %q", path, "john doe", w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestRedirectSlashes tests that GET requests are redirected without trailing slashes, other
+// methods are rewritten and leading slashes cannot produce an off-site redirect
+func TestRedirectSlashes(t *testing.T) {
+	w := httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectSlashes).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/?page=2", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/users?page=2" {
+		t.Errorf("Expected 301 to /users?page=2, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectSlashes).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/", nil))
+	if w.Body.String() != "created" {
+		t.Errorf("Expected POST /users handler to run, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	newPathCaseRouter(chiserver.RedirectSlashes).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "//evil.com/", nil))
+	if loc := w.Header().Get("Location"); loc != "/evil.com" {
+		t.Errorf("Expected a same-site redirect to /evil.com, got %q", loc)
+	}
+}
+
+// TestServer_StripTrailingSlashes tests that the Config fields install the slash middlewares
+func TestServer_StripTrailingSlashes(t *testing.T) {
+	for _, redirect := range []bool{false, true} {
+		server := chiserver.NewServer(chiserver.Config{
+			Logger:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+			StripTrailingSlashes:  true,
+			TrailingSlashRedirect: redirect,
+		}, func(r chi.Router) {
+			r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+		})
+
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+		expected := http.StatusOK
+		if redirect {
+			expected = http.StatusMovedPermanently
+		}
+		if w.Code != expected {
+			t.Errorf("redirect=%v: expected %d, got %d", redirect, expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package chiserver
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultPprofPathPrefix is where the pprof routes are mounted by default.
+const DefaultPprofPathPrefix = "/debug/pprof"
+
+// PprofRoutes registers the net/http/pprof handlers relative to the router
+// it is mounted on: the index at "/", the cmdline, profile, symbol and trace
+// endpoints, and every named profile such as "/heap" or "/goroutine". Mount
+// it under any prefix, e.g. server.Mount("/debug/pprof", PprofRoutes).
+func PprofRoutes(r chi.Router) {
+	r.Get("/", pprof.Index)
+	r.Get("/cmdline", pprof.Cmdline)
+	r.Get("/profile", pprof.Profile)
+	r.Get("/symbol", pprof.Symbol)
+	r.Post("/symbol", pprof.Symbol)
+	r.Get("/trace", pprof.Trace)
+	r.Get("/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
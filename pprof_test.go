@@ -0,0 +1,79 @@
+package chiserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// servePprof sends a GET request to server and returns the recorder
+func servePprof(server *chiserver.Server, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	server.Router().ServeHTTP(w, req)
+	return w
+}
+
+// TestServer_Pprof_DisabledByDefault tests that profiling endpoints are not exposed unless enabled
+func TestServer_Pprof_DisabledByDefault(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	if w := servePprof(server, "/debug/pprof/", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with pprof disabled, got %d", w.Code)
+	}
+}
+
+// TestServer_Pprof_Enabled tests that the index and named profiles are served under the default prefix
+func TestServer_Pprof_Enabled(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		EnablePprof: true,
+	}, func(r chi.Router) {})
+
+	w := servePprof(server, "/debug/pprof/", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("Expected pprof index, got %d", w.Code)
+	}
+
+	w = servePprof(server, "/debug/pprof/goroutine?debug=1", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Errorf("Expected goroutine profile, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get(chiserver.CorrelationIDHeader) == "" {
+		t.Error("Expected pprof routes to pass through the server middlewares")
+	}
+}
+
+// TestServer_Pprof_PrefixAndGuard tests a custom prefix and guarding middleware
+func TestServer_Pprof_PrefixAndGuard(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		EnablePprof:      true,
+		PprofPathPrefix:  "/ops/pprof",
+		PprofMiddlewares: []func(http.Handler) http.Handler{requireAuth},
+	}, func(r chi.Router) {})
+
+	if w := servePprof(server, "/ops/pprof/heap", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", w.Code)
+	}
+
+	auth := http.Header{"Authorization": {"Bearer ops"}}
+	if w := servePprof(server, "/ops/pprof/", auth); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "heap") {
+		t.Errorf("Expected pprof index under the custom prefix, got %d", w.Code)
+	}
+	if w := servePprof(server, "/ops/pprof/heap?debug=1", auth); w.Code != http.StatusOK {
+		t.Errorf("Expected heap profile, got %d", w.Code)
+	}
+}
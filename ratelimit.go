@@ -0,0 +1,127 @@
+package chiserver
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long an unused per-client limiter is kept around.
+const limiterIdleTTL = 10 * time.Minute
+
+// RateLimit is a middleware that gives every client a token bucket refilled
+// at rps requests per second up to burst. Requests exceeding it get 429 Too
+// Many Requests with a Retry-After header. Clients are keyed by IP.
+func RateLimit(rps float64, burst int) func(next http.Handler) http.Handler {
+	limiters := newClientLimiters(rate.Limit(rps), burst)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !limiters.allow(w, clientIP(r), 1) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// CostLimiter is a middleware that gives every client a token bucket refilled
+// at rps tokens per second up to burst, and charges each request costFn(r)
+// tokens. Requests that would overdraw the bucket get 429 Too Many Requests
+// with a Retry-After header. Clients are keyed by IP. Costs below 1 are
+// charged as 1, so a buggy costFn can neither bypass the limit nor refill
+// the bucket.
+func CostLimiter(costFn func(r *http.Request) int, rps, burst int) func(next http.Handler) http.Handler {
+	limiters := newClientLimiters(rate.Limit(rps), burst)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !limiters.allow(w, clientIP(r), max(costFn(r), 1)) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientLimiters holds one token bucket per client and evicts idle ones.
+type clientLimiters struct {
+	limit rate.Limit
+	burst int
+
+	entries   sync.Map // client key -> *clientLimiter
+	lastSweep atomic.Int64
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64
+}
+
+func newClientLimiters(limit rate.Limit, burst int) *clientLimiters {
+	l := &clientLimiters{limit: limit, burst: burst}
+	l.lastSweep.Store(time.Now().UnixNano())
+	return l
+}
+
+// allow charges cost tokens to key's bucket. When the bucket cannot cover
+// the cost it writes a 429 response and returns false.
+func (l *clientLimiters) allow(w http.ResponseWriter, key string, cost int) bool {
+	now := time.Now()
+	l.sweep(now)
+
+	v, _ := l.entries.LoadOrStore(key, &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)})
+	entry := v.(*clientLimiter)
+	entry.lastSeen.Store(now.UnixNano())
+
+	res := entry.limiter.ReserveN(now, cost)
+	if !res.OK() {
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return false
+	}
+	if delay := res.DelayFrom(now); delay > 0 {
+		res.CancelAt(now)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// sweep drops limiters idle for longer than limiterIdleTTL. It runs at most
+// once per TTL, piggybacking on incoming requests.
+func (l *clientLimiters) sweep(now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(limiterIdleTTL) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	l.entries.Range(func(key, v any) bool {
+		if now.UnixNano()-v.(*clientLimiter).lastSeen.Load() > int64(limiterIdleTTL) {
+			l.entries.Delete(key)
+		}
+		return true
+	})
+}
+
+// clientIP returns the client IP resolved by the server's IP middleware,
+// falling back to the host part of RemoteAddr.
+func clientIP(r *http.Request) string {
+	if ip := middleware.GetClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
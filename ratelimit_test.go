@@ -0,0 +1,150 @@
+package chiserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestCostLimiter_ExpensiveRouteDrainsFaster tests that costly requests exhaust the budget sooner
+func TestCostLimiter_ExpensiveRouteDrainsFaster(t *testing.T) {
+	cost := func(r *http.Request) int {
+		if r.URL.Path == "/export" {
+			return 5
+		}
+		return 1
+	}
+	handler := chiserver.CostLimiter(cost, 1, 10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Expensive client: two requests of cost 5 drain a burst of 10
+	for i := 0; i < 2; i++ {
+		if w := send("/export", "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Expected expensive request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := send("/export", "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 once budget is drained, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429")
+	}
+
+	// Cheap client: ten requests of cost 1 fit in the same budget
+	for i := 0; i < 10; i++ {
+		if w := send("/list", "10.0.0.2:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Expected cheap request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+}
+
+// TestCostLimiter_CostAboveBurst tests that a cost exceeding the burst is always rejected
+func TestCostLimiter_CostAboveBurst(t *testing.T) {
+	handler := chiserver.CostLimiter(func(*http.Request) int { return 20 }, 1, 10)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", w.Code)
+	}
+}
+
+// TestCostLimiter_CostBelowOne tests that zero and negative costs are charged as 1
+func TestCostLimiter_CostBelowOne(t *testing.T) {
+	for _, cost := range []int{0, -5} {
+		handler := chiserver.CostLimiter(func(*http.Request) int { return cost }, 1, 3)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		)
+
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("cost %d: expected request %d to pass, got %d", cost, i+1, w.Code)
+			}
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("cost %d: expected 429 once the burst is used, got %d", cost, w.Code)
+		}
+	}
+}
+
+// TestRateLimit_PerClient tests that each client IP gets its own bucket
+func TestRateLimit_PerClient(t *testing.T) {
+	handler := chiserver.RateLimit(1, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d within burst to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := send("10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after the burst, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429")
+	}
+
+	if w := send("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to be unaffected, got %d", w.Code)
+	}
+}
+
+// TestServer_RateLimitConfig tests that Config.RateLimitRPS limits clients resolved from X-Forwarded-For
+func TestServer_RateLimitConfig(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		RateLimitRPS: 1,
+	}, func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	send := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("203.0.113.1"); code != http.StatusOK {
+		t.Fatalf("Expected first request to pass, got %d", code)
+	}
+	if code := send("203.0.113.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected second request from the same client to get 429, got %d", code)
+	}
+	if code := send("203.0.113.2"); code != http.StatusOK {
+		t.Errorf("Expected request from another client to pass, got %d", code)
+	}
+}
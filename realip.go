@@ -0,0 +1,106 @@
+package chiserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Key to use when setting the immediate peer address replaced by RealIP.
+type ctxKeyPeerAddr int
+
+const peerAddrKey ctxKeyPeerAddr = 0
+
+// RealIP is a middleware that resolves the client IP from the
+// X-Forwarded-For or X-Real-IP headers, but only when the immediate peer is
+// one of trustedProxies. Unlike chi's middleware.RealIP, headers sent by
+// untrusted peers are ignored, so clients reaching the server directly
+// cannot spoof their address.
+//
+// X-Forwarded-For is walked from the right, skipping trusted proxies; the
+// first untrusted entry is the client. The resolved IP replaces RemoteAddr
+// and is readable with middleware.GetClientIP, as used by the request log and
+// RateLimit. The original peer address is kept in the request context so
+// that ResolveExternalURL still recognizes the trusted proxy.
+func RealIP(trustedProxies ...netip.Prefix) func(next http.Handler) http.Handler {
+	trusted := make([]netip.Prefix, len(trustedProxies))
+	for i, prefix := range trustedProxies {
+		trusted[i] = prefix.Masked()
+	}
+	isTrusted := func(ip netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		next = middleware.ClientIPFromRemoteAddr(next)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseIP(r.RemoteAddr)
+			if ok && isTrusted(peer) {
+				if ip, ok := forwardedIP(r.Header, peer, isTrusted); ok {
+					ctx := context.WithValue(r.Context(), peerAddrKey, r.RemoteAddr)
+					r = r.WithContext(ctx)
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// forwardedIP returns the client IP reported to a trusted peer.
+func forwardedIP(h http.Header, peer netip.Addr, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, v := range h.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		return parseIP(h.Get("X-Real-IP"))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(hops[i])
+		if !ok {
+			// Nothing left of a malformed entry can be trusted.
+			break
+		}
+		client = ip
+		if !isTrusted(ip) {
+			break
+		}
+	}
+	return client, true
+}
+
+// parseIP parses an IP with an optional port, unmapping IPv4-in-IPv6
+// addresses and dropping zones so they cannot alias a trusted address.
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap().WithZone(""), true
+}
+
+// peerAddr returns the address of the request's immediate peer, as it was
+// before RealIP replaced RemoteAddr.
+func peerAddr(r *http.Request) string {
+	if addr, ok := r.Context().Value(peerAddrKey).(string); ok {
+		return addr
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,84 @@
+package chiserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestRealIP tests that forwarding headers are only honored from trusted peers
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expectedIP string
+	}{
+		{"untrusted peer ignored", "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9"},
+		{"trusted peer", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "1.2.3.4"},
+		{"trusted chain skipped", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "6.6.6.6, 1.2.3.4, 10.0.0.2"}, "1.2.3.4"},
+		{"all hops trusted", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"malformed entry", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, garbage, 10.0.0.2"}, "10.0.0.2"},
+		{"real ip header", "10.0.0.1:1234", map[string]string{"X-Real-IP": "1.2.3.4"}, "1.2.3.4"},
+		{"no headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"ipv6 peer", "[::1]:1234", map[string]string{"X-Forwarded-For": "2001:db8::1"}, "2001:db8::1"},
+		{"mapped peer", "[::ffff:10.0.0.1]:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "1.2.3.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP string
+			handler := chiserver.RealIP(trusted...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotIP = middleware.GetClientIP(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotIP != tt.expectedIP {
+				t.Errorf("Expected client IP %q, got %q", tt.expectedIP, gotIP)
+			}
+		})
+	}
+}
+
+// TestServer_TrustedProxies tests that the Config field replaces the default client IP resolution
+func TestServer_TrustedProxies(t *testing.T) {
+	var gotIP string
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}, func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			gotIP = middleware.GetClientIP(r.Context())
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.9:1234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	server.Router().ServeHTTP(httptest.NewRecorder(), req)
+	if gotIP != "203.0.113.9" {
+		t.Errorf("Expected spoofed header from an untrusted peer to be ignored, got %q", gotIP)
+	}
+
+	req.RemoteAddr = "10.1.2.3:1234"
+	server.Router().ServeHTTP(httptest.NewRecorder(), req)
+	if gotIP != "1.2.3.4" {
+		t.Errorf("Expected forwarded client IP from a trusted peer, got %q", gotIP)
+	}
+}
@@ -0,0 +1,91 @@
+package chiserver
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RecovererOption configures Recoverer.
+type RecovererOption func(*recovererOptions)
+
+type recovererOptions struct {
+	respond  func(w http.ResponseWriter, r *http.Request, recovered any)
+	classify func(recovered any) slog.Level
+}
+
+// WithPanicResponse replaces the response Recoverer writes after a panic.
+func WithPanicResponse(fn func(w http.ResponseWriter, r *http.Request, recovered any)) RecovererOption {
+	return func(o *recovererOptions) {
+		o.respond = fn
+	}
+}
+
+// WithPanicClassifier chooses the level panics are logged at, e.g. Warn for
+// panics libraries use for control flow, so they don't trigger alerts.
+// Panics are logged at Error by default.
+func WithPanicClassifier(fn func(recovered any) slog.Level) RecovererOption {
+	return func(o *recovererOptions) {
+		o.classify = fn
+	}
+}
+
+// Recoverer is a middleware that recovers from handler panics, logs them
+// (at Error level unless classified otherwise) through logger with the
+// correlation ID and stack trace, and answers with a JSON 500 response
+// including the correlation ID. http.ErrAbortHandler is re-panicked so
+// net/http can abort the connection as intended. When the response was
+// already started, e.g. by a streaming handler, the panic is logged and the
+// connection aborted instead, since a 500 can no longer be sent.
+func Recoverer(logger *slog.Logger, opts ...RecovererOption) func(next http.Handler) http.Handler {
+	o := &recovererOptions{respond: defaultPanicResponse, classify: defaultPanicLevel}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				logger.LogAttrs(r.Context(), o.classify(rvr), "panic recovered",
+					slog.Any("panic", rvr),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("correlation_id", GetCorrID(r.Context())),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if ww.Status() != 0 {
+					panic(http.ErrAbortHandler)
+				}
+				if r.Header.Get("Connection") != "Upgrade" {
+					o.respond(ww, r, rvr)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// defaultPanicLevel logs every panic at Error.
+func defaultPanicLevel(recovered any) slog.Level {
+	return slog.LevelError
+}
+
+// defaultPanicResponse writes a generic JSON 500 error carrying the
+// correlation ID.
+func defaultPanicResponse(w http.ResponseWriter, r *http.Request, recovered any) {
+	WriteError(w, r, http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,148 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestRecoverer_LogsAndResponds tests that panics are logged with correlation ID and stack
+func TestRecoverer_LogsAndResponds(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.CorrelationID(chiserver.Recoverer(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/explode", nil)
+	req.Header.Set(chiserver.CorrelationIDHeader, "panic-corr-id")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON error body, got content type %q", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body, got %q: %v", w.Body.String(), err)
+	}
+	if body["error"] != "internal server error" || body["correlation_id"] != "panic-corr-id" {
+		t.Errorf("Expected error body with correlation ID, got %v", body)
+	}
+
+	logOutput := buf.String()
+	for _, field := range []string{`"level":"ERROR"`, `"panic":"boom"`, `"correlation_id":"panic-corr-id"`, `"stack":"`} {
+		if !strings.Contains(logOutput, field) {
+			t.Errorf("Expected log to contain %s, got: %s", field, logOutput)
+		}
+	}
+}
+
+// TestRecoverer_CustomResponse tests that the panic response can be replaced
+func TestRecoverer_CustomResponse(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+
+	respond := chiserver.WithPanicResponse(func(w http.ResponseWriter, r *http.Request, recovered any) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("try again later"))
+	})
+	handler := chiserver.Recoverer(logger, respond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "try again later" {
+		t.Errorf("Expected custom response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestRecoverer_RepanicsAbortHandler tests that http.ErrAbortHandler is propagated
+func TestRecoverer_RepanicsAbortHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	handler := chiserver.Recoverer(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rvr := recover(); rvr != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be re-panicked, got %v", rvr)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// TestRecoverer_AbortsStartedResponse tests that a panic after the response started is logged and
+// aborts the connection instead of appending an error body
+func TestRecoverer_AbortsStartedResponse(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := chiserver.Recoverer(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if rvr := recover(); rvr != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler, got %v", rvr)
+			}
+		}()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	if w.Body.String() != "partial" {
+		t.Errorf("Expected nothing appended to the started response, got %q", w.Body.String())
+	}
+	if !strings.Contains(buf.String(), `"panic":"boom"`) {
+		t.Errorf("Expected the panic to be logged, got: %s", buf.String())
+	}
+}
+
+// errScanDone is a sentinel panic value used for control flow in tests
+var errScanDone = errors.New("scan done")
+
+// TestRecoverer_PanicClassifier tests that a classifier downgrades expected panics to Warn
+func TestRecoverer_PanicClassifier(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	classify := chiserver.WithPanicClassifier(func(recovered any) slog.Level {
+		if err, ok := recovered.(error); ok && errors.Is(err, errScanDone) {
+			return slog.LevelWarn
+		}
+		return slog.LevelError
+	})
+
+	for value, expected := range map[any]string{
+		errScanDone: `"level":"WARN"`,
+		"boom":      `"level":"ERROR"`,
+	} {
+		buf.Reset()
+		handler := chiserver.Recoverer(logger, classify)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(value)
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected panic %v to log %s, got: %s", value, expected, buf.String())
+		}
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500 for panic %v, got %d", value, w.Code)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package chiserver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errRedirectServe marks the failure of the HTTP redirect server as the
+// cause that stops Run.
+var errRedirectServe = errors.New("redirect server")
+
+// httpsRedirectHandler answers every request with a 301 to the same URL on
+// the server's HTTPS port.
+func (s *Server) httpsRedirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if addr, ok := s.Addr().(*net.TCPAddr); ok && addr.Port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(addr.Port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// DefaultForwardedProtoHeader is the header RedirectToHTTPS reads the
+// original scheme from by default.
+const DefaultForwardedProtoHeader = "X-Forwarded-Proto"
+
+// HTTPSRedirectOption configures RedirectToHTTPS.
+type HTTPSRedirectOption func(*httpsRedirectOptions)
+
+type httpsRedirectOptions struct {
+	header       string
+	exemptPaths  map[string]struct{}
+	exemptPrefix []string
+}
+
+// WithForwardedProtoHeader reads the original scheme from header instead of
+// DefaultForwardedProtoHeader.
+func WithForwardedProtoHeader(header string) HTTPSRedirectOption {
+	return func(o *httpsRedirectOptions) {
+		o.header = header
+	}
+}
+
+// WithExemptPaths serves the given paths over plain HTTP, e.g. ACME
+// challenges. Paths ending in "*" match by prefix, like WithSkipPaths.
+func WithExemptPaths(paths ...string) HTTPSRedirectOption {
+	return func(o *httpsRedirectOptions) {
+		for _, p := range paths {
+			if prefix, ok := strings.CutSuffix(p, "*"); ok {
+				o.exemptPrefix = append(o.exemptPrefix, prefix)
+				continue
+			}
+			o.exemptPaths[p] = struct{}{}
+		}
+	}
+}
+
+// RedirectToHTTPS is a middleware for servers behind a TLS-terminating proxy,
+// such as a load balancer. When the forwarded-proto header reports that the
+// client used plain HTTP, it redirects to the https URL: 301 for GET and HEAD
+// and 308 for other methods, so their bodies are resent. Requests without the
+// header are served as usual.
+func RedirectToHTTPS(opts ...HTTPSRedirectOption) func(next http.Handler) http.Handler {
+	o := &httpsRedirectOptions{header: DefaultForwardedProtoHeader, exemptPaths: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			// Proxies may append their own value; the first is the client's.
+			proto, _, _ := strings.Cut(r.Header.Get(o.header), ",")
+			if !strings.EqualFold(strings.TrimSpace(proto), "http") || o.exempt(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			status := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				status = http.StatusMovedPermanently
+			}
+			http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), status)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// exempt reports whether path may be served over plain HTTP.
+func (o *httpsRedirectOptions) exempt(path string) bool {
+	if _, ok := o.exemptPaths[path]; ok {
+		return true
+	}
+	for _, prefix := range o.exemptPrefix {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
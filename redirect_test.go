@@ -0,0 +1,58 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestRedirectToHTTPS tests redirects based on the forwarded scheme, custom headers and exempt paths
+func TestRedirectToHTTPS(t *testing.T) {
+	handler := chiserver.RedirectToHTTPS(
+		chiserver.WithExemptPaths("/healthz", "/.well-known/acme-challenge/*"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	custom := chiserver.RedirectToHTTPS(
+		chiserver.WithForwardedProtoHeader("X-Scheme"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		method   string
+		target   string
+		header   string
+		proto    string
+		status   int
+		location string
+	}{
+		{"http redirected", handler, http.MethodGet, "/orders?page=2", "X-Forwarded-Proto", "http", http.StatusMovedPermanently, "https://example.com/orders?page=2"},
+		{"proxy chain", handler, http.MethodGet, "/", "X-Forwarded-Proto", "http, https", http.StatusMovedPermanently, "https://example.com/"},
+		{"post keeps method", handler, http.MethodPost, "/orders", "X-Forwarded-Proto", "http", http.StatusPermanentRedirect, "https://example.com/orders"},
+		{"https served", handler, http.MethodGet, "/orders", "X-Forwarded-Proto", "https", http.StatusOK, ""},
+		{"no header served", handler, http.MethodGet, "/orders", "", "", http.StatusOK, ""},
+		{"exempt path", handler, http.MethodGet, "/healthz", "X-Forwarded-Proto", "http", http.StatusOK, ""},
+		{"exempt prefix", handler, http.MethodGet, "/.well-known/acme-challenge/token", "X-Forwarded-Proto", "http", http.StatusOK, ""},
+		{"custom header", custom, http.MethodGet, "/orders", "X-Scheme", "http", http.StatusMovedPermanently, "https://example.com/orders"},
+		{"default header ignored", custom, http.MethodGet, "/orders", "X-Forwarded-Proto", "http", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.proto)
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.location {
+				t.Errorf("Expected Location %q, got %q", tt.location, loc)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package chiserver
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteInfo describes a route registered on the server.
+type RouteInfo struct {
+	Method  string
+	Pattern string
+}
+
+// Router returns the server's router, to register more handlers, walk the
+// routes with chi.Walk or set NotFound/MethodNotAllowed handlers after
+// NewServer. Routes must not be changed once Run has started.
+func (s *Server) Router() chi.Router {
+	return s.router
+}
+
+// Routes returns the server's routes, including built-in ones such as the
+// health probes, sorted by pattern and method. It needs no running server,
+// which makes it suitable for contract tests.
+func (s *Server) Routes() []RouteInfo {
+	var routes []RouteInfo
+	chi.Walk(s.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, RouteInfo{Method: method, Pattern: route})
+		return nil
+	})
+
+	slices.SortFunc(routes, func(a, b RouteInfo) int {
+		return cmp.Or(cmp.Compare(a.Pattern, b.Pattern), cmp.Compare(a.Method, b.Method))
+	})
+	return routes
+}
+
+// errStopWalk ends a chi.Walk early once the route was found.
+var errStopWalk = errors.New("stop walk")
+
+// closureSuffix matches the suffixes the compiler gives closures and method
+// values, e.g. ".func1" or "-fm".
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// MiddlewareFor returns the names of the middlewares that run for a request
+// with method and path, outermost first, including the server's own chain and
+// those added with Use, With or Group along the route. Names are qualified by
+// the last import path element, e.g. "chi_server.RateLimit" for the
+// middleware returned by RateLimit. It returns nil when no route matches.
+// Like Routes, it is meant for debugging and tests rather than request
+// handling.
+func (s *Server) MiddlewareFor(method, path string) []string {
+	pattern := s.router.Find(chi.NewRouteContext(), method, path)
+	if pattern == "" {
+		return nil
+	}
+
+	var names []string
+	chi.Walk(s.router, func(m, route string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if m != method || route != pattern {
+			return nil
+		}
+		names = make([]string, 0, len(middlewares))
+		for _, mw := range middlewares {
+			names = append(names, middlewareName(mw))
+		}
+		return errStopWalk
+	})
+	return names
+}
+
+// middlewareName returns the package-qualified name of the function that
+// created mw, stripping closure suffixes.
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return closureSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,186 @@
+package chiserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestServer_Routes tests that registered routes are listed without starting the server
+func TestServer_Routes(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{HealthPath: "/healthz"}, func(r chi.Router) {
+		r.Route("/api/v1", func(r chi.Router) {
+			r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+			r.Post("/users", func(w http.ResponseWriter, r *http.Request) {})
+			r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	})
+
+	routes := server.Routes()
+	for _, expected := range []chiserver.RouteInfo{
+		{Method: http.MethodGet, Pattern: "/api/v1/users"},
+		{Method: http.MethodPost, Pattern: "/api/v1/users"},
+		{Method: http.MethodGet, Pattern: "/api/v1/users/{id}"},
+		{Method: http.MethodGet, Pattern: "/healthz"},
+	} {
+		if !slices.Contains(routes, expected) {
+			t.Errorf("Expected routes to contain %+v, got %+v", expected, routes)
+		}
+	}
+
+	if slices.Contains(routes, chiserver.RouteInfo{Method: http.MethodDelete, Pattern: "/api/v1/users"}) {
+		t.Error("Expected unregistered DELETE /api/v1/users to be absent")
+	}
+}
+
+// TestServer_MultipleConfiguratorsAndMount tests that route modules compose and inherit the common middlewares
+func TestServer_MultipleConfiguratorsAndMount(t *testing.T) {
+	users := func(r chi.Router) {
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("users")) })
+	}
+	orders := func(r chi.Router) {
+		r.Get("/orders", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("orders")) })
+	}
+
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, users, orders)
+
+	server.Mount("/admin", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Admin") == "" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Get("/stats", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("stats")) })
+	})
+
+	tests := []struct {
+		path     string
+		admin    bool
+		expected int
+	}{
+		{"/users", false, http.StatusOK},
+		{"/orders", false, http.StatusOK},
+		{"/admin/stats", false, http.StatusForbidden},
+		{"/admin/stats", true, http.StatusOK},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+	<-server.Started()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+server.Addr().String()+tt.path, nil)
+		if tt.admin {
+			req.Header.Set("X-Admin", "1")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected request to %s to succeed, got: %v", tt.path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.expected {
+			t.Errorf("%s (admin=%v): expected status %d, got %d", tt.path, tt.admin, tt.expected, resp.StatusCode)
+		}
+		if resp.Header.Get(chiserver.CorrelationIDHeader) == "" {
+			t.Errorf("%s: expected the common middleware chain to set a correlation ID", tt.path)
+		}
+	}
+}
+
+// TestServer_Router tests that routes added through Router are served with the common middlewares
+func TestServer_Router(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	server.Router().Get("/late", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("late"))
+	})
+	server.Router().NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for path, expected := range map[string]int{
+		"/late":    http.StatusOK,
+		"/missing": http.StatusTeapot,
+	} {
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d", path, expected, w.Code)
+		}
+		if w.Header().Get(chiserver.CorrelationIDHeader) == "" {
+			t.Errorf("%s: expected the common middleware chain to run", path)
+		}
+	}
+
+	if !slices.Contains(server.Routes(), chiserver.RouteInfo{Method: http.MethodGet, Pattern: "/late"}) {
+		t.Error("Expected Routes to include /late")
+	}
+}
+
+// requireAuth rejects requests without an Authorization header
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestServer_MiddlewareFor tests that group middlewares are listed only for routes inside the group
+func TestServer_MiddlewareFor(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{RateLimitRPS: 10}, func(r chi.Router) {
+		r.Get("/public", func(w http.ResponseWriter, r *http.Request) {})
+		r.Route("/api", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(requireAuth)
+				r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+			})
+		})
+	})
+
+	private := server.MiddlewareFor(http.MethodGet, "/api/users/42")
+	if !slices.Contains(private, "chi_server_test.requireAuth") {
+		t.Errorf("Expected requireAuth for the protected route, got %v", private)
+	}
+	if !slices.Contains(private, "chi_server.RateLimit") {
+		t.Errorf("Expected server middlewares for the protected route, got %v", private)
+	}
+
+	public := server.MiddlewareFor(http.MethodGet, "/public")
+	if len(public) == 0 || slices.Contains(public, "chi_server_test.requireAuth") {
+		t.Errorf("Expected server middlewares without requireAuth for the public route, got %v", public)
+	}
+
+	if mws := server.MiddlewareFor(http.MethodGet, "/missing"); mws != nil {
+		t.Errorf("Expected nil for an unmatched path, got %v", mws)
+	}
+}
@@ -0,0 +1,35 @@
+package chiserver
+
+import (
+	"context"
+	"net/http"
+)
+
+// Key to use when setting the sampling decision.
+type ctxKeySampled int
+
+const sampledKey ctxKeySampled = 0
+
+// Sampling is a middleware that asks sampler whether the request is sampled
+// and stores the decision in the request context for IsSampled.
+func Sampling(sampler func(r *http.Request) bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(ContextWithSampled(r.Context(), sampler(r)))
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ContextWithSampled returns a copy of ctx carrying the sampling decision
+func ContextWithSampled(ctx context.Context, sampled bool) context.Context {
+	return context.WithValue(ctx, sampledKey, sampled)
+}
+
+// IsSampled reports whether the request was sampled. Handlers can use it to
+// gate expensive diagnostics. It returns false when no decision was made.
+func IsSampled(ctx context.Context) bool {
+	sampled, _ := ctx.Value(sampledKey).(bool)
+	return sampled
+}
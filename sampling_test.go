@@ -0,0 +1,36 @@
+package chiserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestSampling_ExposesDecision tests that IsSampled matches the sampler decision
+func TestSampling_ExposesDecision(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		var got bool
+		handler := chiserver.Sampling(func(*http.Request) bool { return sampled })(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = chiserver.IsSampled(r.Context())
+			}),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != sampled {
+			t.Errorf("Expected IsSampled to be %v, got %v", sampled, got)
+		}
+	}
+}
+
+// TestIsSampled_DefaultsToFalse tests that IsSampled is false without a decision
+func TestIsSampled_DefaultsToFalse(t *testing.T) {
+	if chiserver.IsSampled(context.Background()) {
+		t.Error("Expected IsSampled to be false without a sampling decision")
+	}
+}
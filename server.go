@@ -2,95 +2,953 @@ package chiserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/acme/autocert"
 )
 
+// DefaultShutdownTimeout is used when Config.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// ErrShutdownTimeout is returned by Run when in-flight requests did not finish
+// within the configured shutdown timeout.
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// ErrServerAlreadyRunning is returned by Run when the server is already
+// running.
+var ErrServerAlreadyRunning = errors.New("server already running")
+
+// ErrServerClosed is returned by Run when the server has already run and shut
+// down. A Server runs at most once; create a new one to serve again.
+var ErrServerClosed = errors.New("server closed")
+
+// Lifecycle states of a Server.
+const (
+	stateIdle int32 = iota
+	stateRunning
+	stateClosed
+)
+
+// ErrAddrInUse is returned by Run when Addr is already bound by another
+// process. The error text includes the attempted address.
+var ErrAddrInUse = errors.New("address already in use")
+
 // Config holds configuration options for the server.
 type Config struct {
 	Addr   string
 	Logger *slog.Logger
+
+	// ShutdownTimeout bounds how long Run waits for in-flight requests to
+	// drain. Defaults to DefaultShutdownTimeout when zero.
+	ShutdownTimeout time.Duration
+	// ShutdownHookTimeout bounds the shutdown hooks, which get a fresh
+	// context once the HTTP server stopped, so a drain that timed out does
+	// not leave them an expired one. Defaults to ShutdownTimeout when zero.
+	ShutdownHookTimeout time.Duration
+
+	// CertFile and KeyFile enable HTTPS when both are set.
+	CertFile string
+	KeyFile  string
+
+	// Listener, when set, is served instead of listening on Addr. Useful for
+	// Unix domain sockets, socket activation or pre-bound test listeners.
+	Listener net.Listener
+
+	// Middlewares, when non-nil, replaces the default middleware chain
+	// returned by DefaultMiddlewares. Optional middlewares enabled by other
+	// fields are still appended after it.
+	Middlewares []func(http.Handler) http.Handler
+
+	// TrustedProxies, when set, makes the default chain resolve client IPs
+	// with RealIP, honoring forwarding headers only from peers within these
+	// ranges. Otherwise the rightmost X-Forwarded-For entry is used.
+	TrustedProxies []netip.Prefix
+
+	// CorrelationIDHeader is the header the default chain reads and sets
+	// the correlation ID in, and InjectCorrID forwards it in from handlers.
+	// Defaults to the CorrelationIDHeader variable.
+	CorrelationIDHeader string
+
+	// DisableRequestID, DisableRealIP, DisableRecoverer and
+	// DisableRequestLogger drop single middlewares from the default chain,
+	// keeping the order of the rest. They have no effect when Middlewares is
+	// set.
+	DisableRequestID     bool
+	DisableRealIP        bool
+	DisableRecoverer     bool
+	DisableRequestLogger bool
+
+	// CORS, when set, adds the CORS middleware to the chain.
+	CORS *CORSOptions
+
+	// CompressLevel enables gzip response compression at the given
+	// compress/gzip level (e.g. gzip.DefaultCompression). Zero disables it.
+	CompressLevel int
+
+	// HandlerTimeout bounds handler execution; slower requests get 503.
+	// Routes can override it with RouteTimeout. Zero disables it.
+	HandlerTimeout time.Duration
+
+	// MaxRequestBodyBytes limits request bodies; larger ones get 413.
+	// Zero disables the limit.
+	MaxRequestBodyBytes int64
+
+	// DefaultContentType is set on responses whose handler did not set a
+	// Content-Type, e.g. "application/json" for JSON-only APIs.
+	DefaultContentType string
+
+	// EnableStatus mounts a JSON status endpoint at StatusPath reporting
+	// uptime, in-flight and total requests and the goroutine count.
+	EnableStatus bool
+	// StatusPath defaults to DefaultStatusPath.
+	StatusPath string
+
+	// TracerProvider enables OpenTelemetry tracing of every request when set.
+	TracerProvider trace.TracerProvider
+
+	// EnableMetrics records Prometheus request metrics and serves them at
+	// MetricsPath.
+	EnableMetrics bool
+	// MetricsPath defaults to DefaultMetricsPath.
+	MetricsPath string
+	// MetricsPushURL, when set, pushes the metrics to this Pushgateway during
+	// shutdown under MetricsPushJob (default DefaultMetricsPushJob).
+	MetricsPushURL string
+	MetricsPushJob string
+
+	// PreShutdownDelay keeps serving for this long after the shutdown signal,
+	// with readiness reporting unavailable, so load balancers can deregister
+	// the server before it stops accepting connections. Zero disables it.
+	PreShutdownDelay time.Duration
+
+	// AccessLogFormat selects the request log of the default middleware
+	// chain: AccessLogSlog (the default) logs through Logger, AccessLogECS
+	// does too with Elastic Common Schema field names, while
+	// AccessLogCombined writes Combined Log Format lines to AccessLogWriter,
+	// which defaults to os.Stdout.
+	AccessLogFormat string
+	AccessLogWriter io.Writer
+
+	// Favicon and RobotsTxt are served at FaviconPath and RobotsPath. An empty
+	// Favicon answers 204 and an empty RobotsTxt serves DefaultRobotsTxt.
+	// Routes registered by the RouteConfigurator take precedence.
+	Favicon   []byte
+	RobotsTxt string
+
+	// NotFoundHandler and MethodNotAllowedHandler, when set, answer requests
+	// matching no route and routes not supporting the method. Nil keeps
+	// chi's defaults.
+	NotFoundHandler         http.HandlerFunc
+	MethodNotAllowedHandler http.HandlerFunc
+
+	// PanicClassifier chooses the level the default Recoverer logs each
+	// recovered panic at. Nil logs every panic at Error.
+	PanicClassifier func(recovered any) slog.Level
+
+	// OnListen, when set, is called by Run with the bound address once the
+	// server accepts connections.
+	OnListen func(addr net.Addr)
+
+	// OnServeError, when set, is called by Run with the error it is about to
+	// return when serving fails after startup, e.g. to flush telemetry or
+	// schedule a restart. It is not called for clean shutdowns.
+	OnServeError func(err error)
+
+	// HealthPath and ReadyPath, when set, mount the liveness and readiness
+	// handlers, e.g. "/healthz" and "/readyz".
+	HealthPath string
+	ReadyPath  string
+
+	// LowercasePaths lowercases request paths before routing so mixed-case
+	// paths match lowercase routes. With LowercasePathRedirect, GET and HEAD
+	// requests are redirected to the lowercase path instead.
+	LowercasePaths        bool
+	LowercasePathRedirect bool
+
+	// StripTrailingSlashes removes trailing slashes from request paths before
+	// routing so /users/ matches /users. With TrailingSlashRedirect, GET and
+	// HEAD requests are redirected to the path without them instead.
+	StripTrailingSlashes  bool
+	TrailingSlashRedirect bool
+
+	// RateLimitRPS enables per-client-IP rate limiting at this many requests
+	// per second, allowing bursts of RateLimitBurst (at least 1). Zero
+	// disables it.
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// EnablePprof mounts the net/http/pprof handlers at PprofPathPrefix
+	// (default DefaultPprofPathPrefix). Profiles expose internals, so guard
+	// them with PprofMiddlewares, e.g. an authentication check.
+	EnablePprof      bool
+	PprofPathPrefix  string
+	PprofMiddlewares []func(http.Handler) http.Handler
+
+	// ContextEnrichers augment every request context right after the
+	// Middlewares chain, so handlers can retrieve app-wide values such as a
+	// logger tagged with the correlation ID. See EnrichContext.
+	ContextEnrichers []func(ctx context.Context, r *http.Request) context.Context
+
+	// EnableContextLogger stores Logger, tagged with the correlation ID, in
+	// every request context for handlers to retrieve with LoggerFromContext.
+	EnableContextLogger bool
+
+	// MaxConcurrentRequests caps how many requests run at once; excess
+	// requests wait up to MaxConcurrentWait and then get 503. A zero wait
+	// queues until the request context ends and a negative one rejects right
+	// away. Zero disables the cap. See Server.ConcurrencyLimiter.
+	MaxConcurrentRequests int
+	MaxConcurrentWait     time.Duration
+
+	// RedirectHTTPAddr, when set together with CertFile and KeyFile or
+	// AutoTLS, makes Run also listen for plain HTTP on this address (e.g.
+	// ":80") and answer every request with a 301 to the HTTPS equivalent.
+	// Both listeners shut down together.
+	RedirectHTTPAddr string
+
+	// AutoTLS obtains and renews certificates from an ACME CA such as Let's
+	// Encrypt, taking precedence over CertFile and KeyFile. The plain HTTP
+	// listener serves the HTTP-01 challenges and defaults to ":http" when
+	// RedirectHTTPAddr is empty. Set the manager's Cache, e.g. to an
+	// autocert.DirCache, or certificates are requested again on every start.
+	AutoTLS *autocert.Manager
 }
 
 // Server defines a reusable HTTP server with slog logging and graceful shutdown.
 type Server struct {
-	httpServer *http.Server
-	logger     *slog.Logger
+	httpServer      *http.Server
+	redirectServer  *http.Server
+	router          *chi.Mux
+	onListen        func(addr net.Addr)
+	onServeError    func(err error)
+	started         chan struct{}
+	startedOnce     sync.Once
+	stop            chan struct{}
+	stopOnce        sync.Once
+	done            chan struct{}
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+	hookTimeout     time.Duration
+	preShutdown     time.Duration
+	certFile        string
+	keyFile         string
+	autoTLS         *autocert.Manager
+	listener        net.Listener
+	liveness        *HealthChecker
+	readiness       *HealthChecker
+	metrics         *prometheus.Registry
+	concurrency     *ConcurrencyLimiter
+
+	state         atomic.Int32
+	inFlight      atomic.Int64
+	requestsTotal atomic.Int64
+	conns         connTracker
+
+	mu        sync.Mutex
+	addr      net.Addr
+	startedAt time.Time
+	runErr    error
+	stopping  bool
+	hooks     []shutdownHook
+}
+
+// shutdownHook is a cleanup callback run by Run after the HTTP server stops.
+type shutdownHook struct {
+	priority int
+	fn       func(context.Context) error
 }
 
 // RouteConfigurator allows injecting custom routes into the router.
 type RouteConfigurator func(r chi.Router)
 
 // NewServer creates a new HTTP server with a configurable heartbeat path and slog logging.
-func NewServer(cfg Config, configureRoutes RouteConfigurator) *Server {
+//
+// Routes are added by each configurator in turn, so larger applications can
+// compose them from independent modules. All of them share the common
+// middleware chain, which they may extend with Use. Built-in routes such as
+// the status, metrics, pprof and health endpoints are added after them and
+// give way to routes the configurators registered on the same path.
+func NewServer(cfg Config, configurators ...RouteConfigurator) *Server {
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.ShutdownHookTimeout == 0 {
+		cfg.ShutdownHookTimeout = cfg.ShutdownTimeout
+	}
+
+	s := &Server{
+		logger:          cfg.Logger,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		hookTimeout:     cfg.ShutdownHookTimeout,
+		preShutdown:     cfg.PreShutdownDelay,
+		certFile:        cfg.CertFile,
+		keyFile:         cfg.KeyFile,
+		autoTLS:         cfg.AutoTLS,
+		listener:        cfg.Listener,
+		liveness:        NewHealthChecker(),
+		readiness:       NewHealthChecker(),
+		metrics:         newMetricsRegistry(),
+		onListen:        cfg.OnListen,
+		onServeError:    cfg.OnServeError,
+		started:         make(chan struct{}),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
 
 	r := chi.NewRouter()
 
 	// Common middlewares
-	r.Use(middleware.RequestID)
-	r.Use(CorrelationID)
-	r.Use(middleware.ClientIPFromXFFTrustedProxies(1))
-	r.Use(middleware.Recoverer)
-	r.Use(RequestLogger(cfg.Logger))
+	r.Use(s.countRequests)
+	if cfg.Middlewares != nil {
+		r.Use(cfg.Middlewares...)
+	} else {
+		r.Use(defaultChain(cfg)...)
+	}
+	if cfg.EnableContextLogger {
+		r.Use(ContextLogger(cfg.Logger))
+	}
+	if len(cfg.ContextEnrichers) > 0 {
+		r.Use(EnrichContext(cfg.ContextEnrichers...))
+	}
+	if cfg.LowercasePaths {
+		if cfg.LowercasePathRedirect {
+			r.Use(RedirectLowercasePath)
+		} else {
+			r.Use(LowercasePath)
+		}
+	}
+	if cfg.StripTrailingSlashes {
+		if cfg.TrailingSlashRedirect {
+			r.Use(RedirectSlashes)
+		} else {
+			r.Use(StripSlashes)
+		}
+	}
+	if cfg.TracerProvider != nil {
+		r.Use(Tracing(cfg.TracerProvider))
+	}
+	if cfg.EnableMetrics {
+		r.Use(Metrics(s.metrics))
+	}
+	if cfg.CORS != nil {
+		r.Use(CORS(*cfg.CORS))
+	}
+	if cfg.RateLimitRPS > 0 {
+		r.Use(RateLimit(cfg.RateLimitRPS, max(cfg.RateLimitBurst, 1)))
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		s.concurrency = NewConcurrencyLimiter(cfg.MaxConcurrentRequests, cfg.MaxConcurrentWait)
+		r.Use(s.concurrency.Handler)
+	}
+	if cfg.CompressLevel != 0 {
+		r.Use(Compress(cfg.CompressLevel))
+	}
+	if cfg.HandlerTimeout > 0 {
+		r.Use(Timeout(cfg.HandlerTimeout))
+	}
+	if cfg.MaxRequestBodyBytes > 0 {
+		r.Use(MaxBodyBytes(cfg.MaxRequestBodyBytes))
+	}
+	if cfg.DefaultContentType != "" {
+		r.Use(DefaultContentType(cfg.DefaultContentType))
+	}
+
+	// Set before any route so mounted sub-routers inherit them.
+	if cfg.NotFoundHandler != nil {
+		r.NotFound(cfg.NotFoundHandler)
+	}
+	if cfg.MethodNotAllowedHandler != nil {
+		r.MethodNotAllowed(cfg.MethodNotAllowedHandler)
+	}
 
 	// Service specific routes
-	configureRoutes(r)
+	for _, configureRoutes := range configurators {
+		configureRoutes(r)
+	}
 
-	srv := &http.Server{
+	// Built-in routes are registered last so configurators can still call
+	// Use, and are skipped where a configurator already routes the path.
+	if cfg.EnableStatus {
+		statusPath := cfg.StatusPath
+		if statusPath == "" {
+			statusPath = DefaultStatusPath
+		}
+		if !r.Match(chi.NewRouteContext(), http.MethodGet, statusPath) {
+			r.Method(http.MethodGet, statusPath, s.StatusHandler())
+		}
+	}
+	if cfg.EnableMetrics {
+		metricsPath := cfg.MetricsPath
+		if metricsPath == "" {
+			metricsPath = DefaultMetricsPath
+		}
+		if !r.Match(chi.NewRouteContext(), http.MethodGet, metricsPath) {
+			r.Method(http.MethodGet, metricsPath, s.MetricsHandler())
+		}
+	}
+	if cfg.EnablePprof {
+		pprofPrefix := cfg.PprofPathPrefix
+		if pprofPrefix == "" {
+			pprofPrefix = DefaultPprofPathPrefix
+		}
+		if !r.Match(chi.NewRouteContext(), http.MethodGet, strings.TrimSuffix(pprofPrefix, "/")+"/") {
+			r.Route(pprofPrefix, func(r chi.Router) {
+				r.Use(cfg.PprofMiddlewares...)
+				PprofRoutes(r)
+			})
+		}
+	}
+	if cfg.HealthPath != "" && !r.Match(chi.NewRouteContext(), http.MethodGet, cfg.HealthPath) {
+		r.Method(http.MethodGet, cfg.HealthPath, s.HealthHandler())
+	}
+	if cfg.ReadyPath != "" && !r.Match(chi.NewRouteContext(), http.MethodGet, cfg.ReadyPath) {
+		r.Method(http.MethodGet, cfg.ReadyPath, s.ReadyHandler())
+	}
+	if !r.Match(chi.NewRouteContext(), http.MethodGet, FaviconPath) {
+		r.Method(http.MethodGet, FaviconPath, FaviconHandler(cfg.Favicon))
+	}
+	if !r.Match(chi.NewRouteContext(), http.MethodGet, RobotsPath) {
+		r.Method(http.MethodGet, RobotsPath, RobotsHandler(cfg.RobotsTxt))
+	}
+
+	s.router = r
+	s.httpServer = &http.Server{
 		Addr:    cfg.Addr,
 		Handler: r,
 	}
-	return &Server{httpServer: srv, logger: cfg.Logger}
+	redirectAddr, redirect := cfg.RedirectHTTPAddr, s.httpsRedirectHandler()
+	if cfg.AutoTLS != nil {
+		s.httpServer.TLSConfig = cfg.AutoTLS.TLSConfig()
+		if redirectAddr == "" {
+			redirectAddr = ":http"
+		}
+		redirect = cfg.AutoTLS.HTTPHandler(redirect)
+	}
+	if redirectAddr != "" && s.tlsEnabled() {
+		s.redirectServer = &http.Server{
+			Addr:    redirectAddr,
+			Handler: redirect,
+		}
+	}
+	if cfg.MetricsPushURL != "" {
+		s.PushMetricsOnShutdown(cfg.MetricsPushURL, cfg.MetricsPushJob)
+	}
+	return s
+}
+
+// HTTPServer returns the underlying http.Server for settings Config does not
+// cover, such as ConnContext, ErrorLog or TLSNextProto. Fields may be changed
+// before Run only; replacing Handler or Addr is unsupported. BaseContext and
+// ConnState are chained with the server's own hooks, so request contexts are
+// still cancelled on shutdown and connections still tracked while draining.
+func (s *Server) HTTPServer() *http.Server {
+	return s.httpServer
+}
+
+// chainHTTPServerHooks installs the hooks Run relies on, calling any set
+// through HTTPServer as well. Request contexts derive from baseCtx.
+func (s *Server) chainHTTPServerHooks(baseCtx context.Context) {
+	if base := s.httpServer.BaseContext; base != nil {
+		s.httpServer.BaseContext = func(ln net.Listener) context.Context {
+			ctx, cancel := context.WithCancel(base(ln))
+			context.AfterFunc(baseCtx, cancel)
+			return ctx
+		}
+	} else {
+		s.httpServer.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	}
+
+	connState := s.httpServer.ConnState
+	s.httpServer.ConnState = func(c net.Conn, state http.ConnState) {
+		s.conns.track(c, state)
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+}
+
+// Mount attaches a route group under pattern, configured on its own
+// sub-router, e.g. one per application module. The group inherits the common
+// middleware chain and may add its own with Use. Mount must be called before
+// Run.
+func (s *Server) Mount(pattern string, configure RouteConfigurator) {
+	s.router.Route(pattern, configure)
+}
+
+// DefaultMiddlewares returns the middleware chain NewServer installs when
+// Config.Middlewares is nil: RequestID, CorrelationID, client IP resolution,
+// Recoverer and RequestLogger, in that order. Requests for FaviconPath and
+// RobotsPath are not logged. Append to it to extend the defaults rather than
+// replace them.
+func DefaultMiddlewares(logger *slog.Logger) []func(http.Handler) http.Handler {
+	return defaultMiddlewares(
+		middleware.RequestID,
+		CorrelationID,
+		middleware.ClientIPFromXFFTrustedProxies(1),
+		Recoverer(logger),
+		RequestLogger(logger, WithSkipPaths(FaviconPath, RobotsPath)),
+	)
+}
+
+// defaultChain returns the default chain as configured by cfg, leaving out
+// the middlewares turned off by its Disable flags.
+func defaultChain(cfg Config) []func(http.Handler) http.Handler {
+	var requestID, resolveIP, recoverer, requestLogger func(http.Handler) http.Handler
+	if !cfg.DisableRequestID {
+		requestID = middleware.RequestID
+	}
+	correlationID := CorrelationIDWithConfig(CorrelationIDConfig{HeaderName: cfg.CorrelationIDHeader})
+	if !cfg.DisableRealIP {
+		resolveIP = middleware.ClientIPFromXFFTrustedProxies(1)
+		if len(cfg.TrustedProxies) > 0 {
+			resolveIP = RealIP(cfg.TrustedProxies...)
+		}
+	}
+	if !cfg.DisableRecoverer {
+		var recovererOpts []RecovererOption
+		if cfg.PanicClassifier != nil {
+			recovererOpts = append(recovererOpts, WithPanicClassifier(cfg.PanicClassifier))
+		}
+		recoverer = Recoverer(cfg.Logger, recovererOpts...)
+	}
+	if !cfg.DisableRequestLogger {
+		requestLogger = accessLogger(cfg)
+	}
+	return defaultMiddlewares(requestID, correlationID, resolveIP, recoverer, requestLogger)
+}
+
+// defaultMiddlewares is the default chain with the given request ID
+// generator, correlation ID middleware, client IP resolver, recoverer and
+// request logger. Nil ones are left out.
+func defaultMiddlewares(requestID, correlationID, resolveIP, recoverer, requestLogger func(http.Handler) http.Handler) []func(http.Handler) http.Handler {
+	var chain []func(http.Handler) http.Handler
+	for _, mw := range []func(http.Handler) http.Handler{requestID, correlationID, resolveIP, recoverer, requestLogger} {
+		if mw != nil {
+			chain = append(chain, mw)
+		}
+	}
+	return chain
+}
+
+// accessLogger returns the request logger selected by cfg.AccessLogFormat.
+func accessLogger(cfg Config) func(http.Handler) http.Handler {
+	if cfg.AccessLogFormat == AccessLogCombined {
+		w := cfg.AccessLogWriter
+		if w == nil {
+			w = os.Stdout
+		}
+		return RequestLoggerCLF(w, WithSkipPaths(FaviconPath, RobotsPath))
+	}
+	if cfg.AccessLogFormat == AccessLogECS {
+		return RequestLogger(cfg.Logger, WithSkipPaths(FaviconPath, RobotsPath), WithECS())
+	}
+	return RequestLogger(cfg.Logger, WithSkipPaths(FaviconPath, RobotsPath))
+}
+
+// Addr returns the address the server is listening on, or nil if Run has not
+// started listening yet. Useful when Config.Addr uses port 0.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// Started returns a channel that is closed once Run is accepting
+// connections, after which Addr is set.
+func (s *Server) Started() <-chan struct{} {
+	return s.started
 }
 
 // Run starts the server and gracefully shuts down on context cancellation.
+// When shutdown begins, after any PreShutdownDelay, the contexts of in-flight
+// requests are cancelled so handlers watching ctx.Done() can stop early.
+//
+// A Server runs once: calling Run while it runs returns
+// ErrServerAlreadyRunning and calling it after it stopped returns
+// ErrServerClosed. Run may be retried when it failed to listen.
 func (s *Server) Run(ctx context.Context) error {
+	if !s.state.CompareAndSwap(stateIdle, stateRunning) {
+		if s.state.Load() == stateRunning {
+			return ErrServerAlreadyRunning
+		}
+		return ErrServerClosed
+	}
+
+	ln, err := s.listen()
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return s.abortRun(fmt.Errorf("listen on %s: %w: %w", s.listenAddr(), ErrAddrInUse, err))
+		}
+		return s.abortRun(fmt.Errorf("server error: %w", err))
+	}
+	var redirectLn net.Listener
+	if s.redirectServer != nil {
+		redirectLn, err = net.Listen("tcp", s.redirectServer.Addr)
+		if err != nil {
+			ln.Close()
+			if errors.Is(err, syscall.EADDRINUSE) {
+				return s.abortRun(fmt.Errorf("listen on %s: %w: %w", s.redirectServer.Addr, ErrAddrInUse, err))
+			}
+			return s.abortRun(fmt.Errorf("redirect server error: %w", err))
+		}
+	}
+
+	err = s.run(ctx, ln, redirectLn)
+	s.mu.Lock()
+	s.runErr = err
+	s.mu.Unlock()
+	s.state.Store(stateClosed)
+	close(s.done)
+	return err
+}
+
+// abortRun ends a Run that failed to listen and returns err. Nothing was
+// served yet, so Run may be retried, unless Stop was called meanwhile: then
+// the server is closed and Stop returns err.
+func (s *Server) abortRun(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopping {
+		s.state.Store(stateIdle)
+		return err
+	}
+	s.runErr = err
+	s.state.Store(stateClosed)
+	close(s.done)
+	return err
+}
+
+// run serves on ln, and redirects from redirectLn when set, until ctx is done
+// or Stop is called, then shuts down.
+func (s *Server) run(ctx context.Context, ln, redirectLn net.Listener) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-s.stop:
+			cancel(errStopped)
+		case <-ctx.Done():
+		}
+	}()
+
+	s.mu.Lock()
+	s.addr = ln.Addr()
+	s.startedAt = time.Now()
+	s.mu.Unlock()
+
+	// The listener is bound, so connections are accepted (queued until Serve
+	// picks them up) from here on.
+	s.startedOnce.Do(func() { close(s.started) })
+	if s.onListen != nil {
+		s.onListen(ln.Addr())
+	}
+
+	// Request contexts derive from baseCtx, which is cancelled when shutdown
+	// begins so long-running handlers can stop early.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+	s.chainHTTPServerHooks(baseCtx)
+
 	errCh := make(chan error, 1)
 
 	go func() {
-		s.logger.Info("server starting", slog.String("addr", s.httpServer.Addr))
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+		s.logger.Info("server starting",
+			slog.String("addr", ln.Addr().String()),
+			slog.Bool("tls", s.tlsEnabled()),
+		)
+		err := s.serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
 		}
+		errCh <- err
 	}()
+	if redirectLn != nil {
+		go func() {
+			s.logger.Info("redirect server starting", slog.String("addr", redirectLn.Addr().String()))
+			if err := s.redirectServer.Serve(redirectLn); !errors.Is(err, http.ErrServerClosed) {
+				cancel(fmt.Errorf("%w: %w", errRedirectServe, err))
+			}
+		}()
+	}
 
 	select {
 	case <-ctx.Done():
-		s.logger.Info("shutdown signal received")
-		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		s.logger.Info("shutdown signal received", slog.String("reason", shutdownReason(ctx)))
+		s.readiness.SetDraining(true)
+		if s.preShutdown > 0 {
+			s.logger.Info("draining before shutdown", slog.Duration("delay", s.preShutdown))
+			time.Sleep(s.preShutdown)
+		}
+
+		shutCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 		defer cancel()
+		cancelBase()
+
+		var errs []error
+		cause := context.Cause(ctx)
+		serveFailed := errors.Is(cause, errRedirectServe)
+		if serveFailed {
+			errs = append(errs, cause)
+		}
+		if s.redirectServer != nil {
+			if err := s.redirectServer.Shutdown(shutCtx); err != nil {
+				s.redirectServer.Close()
+			}
+		}
+		drained := make(chan struct{})
+		go s.logDrain(drained)
+		err := s.httpServer.Shutdown(shutCtx)
+		close(drained)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				errs = append(errs, fmt.Errorf("shutdown: %w after %s: %w", ErrShutdownTimeout, s.shutdownTimeout, err))
+				// Stuck handlers would otherwise keep their connections
+				// open; close them so the process can exit.
+				s.logger.Warn("forcibly closing connections", slog.Int("connections", s.conns.open()))
+				s.httpServer.Close()
+			} else {
+				errs = append(errs, fmt.Errorf("shutdown: %w", err))
+			}
+		}
+		// Serve returns once Shutdown closes the listener. Collect its result
+		// so a failure racing the cancellation (e.g. unreadable TLS
+		// certificates) is reported rather than lost.
+		if err := <-errCh; err != nil {
+			serveFailed = true
+			errs = append(errs, fmt.Errorf("server error: %w", err))
+		}
+		errs = append(errs, s.runShutdownHooks())
 
-		if err := s.httpServer.Shutdown(shutCtx); err != nil {
-			return fmt.Errorf("shutdown: %w", err)
+		if err := errors.Join(errs...); err != nil {
+			if serveFailed {
+				s.serveFailed(err)
+			}
+			return err
 		}
-		s.logger.Info("server gracefully stopped")
+		s.logger.Info("server gracefully stopped",
+			slog.Duration("uptime", s.uptime()),
+			slog.Int64("requests", s.RequestCount()),
+		)
 
 	case err := <-errCh:
-		return fmt.Errorf("server error: %w", err)
+		if s.redirectServer != nil {
+			s.redirectServer.Close()
+		}
+		if err == nil {
+			return nil
+		}
+		s.logger.Error("server stopped unexpectedly",
+			slog.String("reason", ReasonServeError),
+			slog.String("error", err.Error()),
+		)
+		err = fmt.Errorf("server error: %w", err)
+		if hookErr := s.runShutdownHooks(); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+		s.serveFailed(err)
+		return err
 	}
 
 	return nil
 }
 
+// Stop gracefully shuts down the server started with Run, as if Run's
+// context had been cancelled, and waits for Run to return. The in-progress
+// Run returns nil after a clean shutdown; Stop returns the same result, or
+// ctx's error when ctx ends first, in which case shutdown continues in the
+// background. Stopping and cancelling Run's context are interchangeable and
+// may both happen; the first one starts the shutdown. Stop on a server that
+// was never run marks it closed, so a later Run returns ErrServerClosed.
+// Stop during a Run that fails to listen closes the server and returns
+// Run's error.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.state.CompareAndSwap(stateIdle, stateClosed) {
+		close(s.done)
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopping = true
+	s.mu.Unlock()
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.done:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.runErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterOnShutdown registers a cleanup callback that Run invokes after the
+// HTTP server has stopped accepting requests. Hooks run sequentially in
+// ascending priority order; hooks sharing a priority run in reverse
+// registration order, like deferred calls. Hooks also run when serving
+// fails. They share a context bounded by Config.ShutdownHookTimeout.
+func (s *Server) RegisterOnShutdown(priority int, fn func(context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{priority: priority, fn: fn})
+}
+
+// OnShutdown registers a cleanup callback at the default priority (0). Hooks
+// registered this way run in LIFO order once the HTTP server has stopped.
+func (s *Server) OnShutdown(fn func(context.Context) error) {
+	s.RegisterOnShutdown(0, fn)
+}
+
+// runShutdownHooks runs the registered hooks with a context bounded by the
+// hook timeout and joins their errors.
+func (s *Server) runShutdownHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.hookTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	hooks := slices.Clone(s.hooks)
+	s.mu.Unlock()
+
+	slices.Reverse(hooks)
+	slices.SortStableFunc(hooks, func(a, b shutdownHook) int {
+		return a.priority - b.priority
+	})
+
+	var errs []error
+	for _, h := range hooks {
+		if err := h.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listen returns the configured listener or binds a new one on Addr.
+func (s *Server) listen() (net.Listener, error) {
+	if s.listener != nil {
+		return s.listener, nil
+	}
+
+	return net.Listen("tcp", s.listenAddr())
+}
+
+// listenAddr returns Addr, defaulting to the HTTP or HTTPS port.
+func (s *Server) listenAddr() string {
+	if s.httpServer.Addr != "" {
+		return s.httpServer.Addr
+	}
+	if s.tlsEnabled() {
+		return ":https"
+	}
+	return ":http"
+}
+
+// tlsEnabled reports whether AutoTLS or both a certificate and a key were
+// configured.
+func (s *Server) tlsEnabled() bool {
+	return s.autoTLS != nil || s.certFile != "" && s.keyFile != ""
+}
+
+// serve accepts connections on ln, using TLS when configured.
+func (s *Server) serve(ln net.Listener) error {
+	if s.autoTLS != nil {
+		// Certificates come from TLSConfig.GetCertificate.
+		return s.httpServer.ServeTLS(ln, "", "")
+	}
+	if s.tlsEnabled() {
+		return s.httpServer.ServeTLS(ln, s.certFile, s.keyFile)
+	}
+	return s.httpServer.Serve(ln)
+}
+
+// Shutdown reasons reported in the "reason" field of lifecycle logs.
+const (
+	ReasonSignal           = "signal"
+	ReasonContextCancelled = "context_cancelled"
+	ReasonServeError       = "serve_error"
+	ReasonStopped          = "stopped"
+)
+
+// errStopped is the cancellation cause of Run's context after Stop.
+var errStopped = errors.New("server stopped")
+
+// SignalError is the cancellation cause of contexts returned by
+// WaitForSignal and WaitForSignalWith when a signal arrives.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "received signal " + e.Signal.String()
+}
+
+// serveFailed passes err to the OnServeError callback, if any.
+func (s *Server) serveFailed(err error) {
+	if s.onServeError != nil {
+		s.onServeError(err)
+	}
+}
+
+// shutdownReason classifies why ctx is done.
+func shutdownReason(ctx context.Context) string {
+	var sigErr *SignalError
+	if errors.As(context.Cause(ctx), &sigErr) {
+		return ReasonSignal
+	}
+	if errors.Is(context.Cause(ctx), errStopped) {
+		return ReasonStopped
+	}
+	if errors.Is(context.Cause(ctx), errRedirectServe) {
+		return ReasonServeError
+	}
+	return ReasonContextCancelled
+}
+
 // WaitForSignal returns a context canceled on SIGINT/SIGTERM.
 func WaitForSignal() context.Context {
-	ctx, cancel := context.WithCancel(context.Background())
+	return WaitForSignalWith(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// WaitForSignalWith returns a context derived from parent that is canceled when
+// parent is done or any of the given signals arrives. With no signals it
+// listens for SIGINT and SIGTERM. When a signal cancels the context,
+// context.Cause reports a *SignalError.
+func WaitForSignalWith(parent context.Context, signals ...os.Signal) context.Context {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(c, signals...)
 	go func() {
-		<-c
-		cancel()
+		defer signal.Stop(c)
+		select {
+		case sig := <-c:
+			cancel(&SignalError{Signal: sig})
+		case <-ctx.Done():
+		}
 	}()
 	return ctx
 }
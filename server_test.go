@@ -1,18 +1,37 @@
 package chiserver_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/crypto/acme/autocert"
 
 	"github.com/pmatteo/chi_server"
+	"github.com/pmatteo/chi_server/chiservertest"
 )
 
 // TestNewServer_WithDefaultLogger tests server creation with default logger
@@ -55,23 +74,42 @@ func TestNewServer_WithCustomLogger(t *testing.T) {
 // TestNewServer_RouteConfiguration tests that custom routes are properly configured
 func TestNewServer_RouteConfiguration(t *testing.T) {
 	cfg := chiserver.Config{
-		Addr:   ":0", // Use dynamic port
 		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
-	server := chiserver.NewServer(cfg, func(r chi.Router) {
+	baseURL, client := chiservertest.StartTestServer(t, cfg, func(r chi.Router) {
 		r.Get("/custom", func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("custom route"))
 		})
 	})
 
-	if server == nil {
-		t.Fatal("Expected server to be created")
+	resp, err := client.Get(baseURL + "/custom")
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "custom route" {
+		t.Errorf("Expected body 'custom route', got %q", body)
+	}
+}
+
+// TestServer_Addr tests that Addr reports the bound address once Run is listening
+func TestServer_Addr(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	if addr := server.Addr(); addr != nil {
+		t.Errorf("Expected nil address before Run, got %v", addr)
 	}
 
-	// Start server in background
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	errCh := make(chan error, 1)
@@ -79,12 +117,20 @@ func TestNewServer_RouteConfiguration(t *testing.T) {
 		errCh <- server.Run(ctx)
 	}()
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
+	<-server.Started()
+
+	tcpAddr, ok := server.Addr().(*net.TCPAddr)
+	if !ok {
+		t.Fatalf("Expected *net.TCPAddr, got %T", server.Addr())
+	}
+	if tcpAddr.Port == 0 {
+		t.Error("Expected a concrete port, got 0")
+	}
 
-	// Note: This test verifies server creation and route configuration
-	// but doesn't make actual HTTP requests since the server address is dynamic
-	// For full integration testing, you'd need to extract the actual port
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
 }
 
 // TestServer_Run_ContextCancellation tests graceful shutdown on context cancellation
@@ -175,6 +221,28 @@ func TestServer_Run_InvalidAddress(t *testing.T) {
 	}
 }
 
+// TestServer_Run_ServeErrorWithCancelledContext tests that a serve failure is not lost when the
+// context is already cancelled
+func TestServer_Run_ServeErrorWithCancelledContext(t *testing.T) {
+	dir := t.TempDir()
+	cfg := chiserver.Config{
+		Addr:     "127.0.0.1:0",
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		CertFile: filepath.Join(dir, "missing.pem"),
+		KeyFile:  filepath.Join(dir, "missing-key.pem"),
+	}
+
+	for i := 0; i < 20; i++ {
+		server := chiserver.NewServer(cfg, func(r chi.Router) {})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := server.Run(ctx); err == nil {
+			t.Fatal("Expected TLS certificate error, got nil")
+		}
+	}
+}
+
 // TestServer_MultipleRoutes tests server with multiple route configurations
 func TestServer_MultipleRoutes(t *testing.T) {
 	cfg := chiserver.Config{
@@ -250,10 +318,43 @@ func TestWaitForSignal_SIGTERMSignal(t *testing.T) {
 	}
 }
 
+// TestWaitForSignalWith_CustomSignal tests WaitForSignalWith with a custom signal
+func TestWaitForSignalWith_CustomSignal(t *testing.T) {
+	ctx := chiserver.WaitForSignalWith(context.Background(), syscall.SIGHUP)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(syscall.SIGHUP)
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Expected - context was cancelled by SIGHUP
+	case <-time.After(2 * time.Second):
+		t.Fatal("Context was not cancelled after SIGHUP")
+	}
+}
+
+// TestWaitForSignalWith_ParentCancellation tests that cancelling the parent cancels the context
+func TestWaitForSignalWith_ParentCancellation(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	ctx := chiserver.WaitForSignalWith(parent, syscall.SIGHUP)
+
+	cancel()
+
+	select {
+	case <-ctx.Done():
+		// Expected - parent cancellation propagates
+	case <-time.After(2 * time.Second):
+		t.Fatal("Context was not cancelled after parent cancellation")
+	}
+}
+
 // TestServer_Integration tests full server lifecycle
 func TestServer_Integration(t *testing.T) {
 	cfg := chiserver.Config{
-		Addr:   ":0",
+		Addr:   "127.0.0.1:0",
 		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
@@ -273,7 +374,17 @@ func TestServer_Integration(t *testing.T) {
 	}()
 
 	// Wait for server to start
-	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-server.Started():
+	case <-time.After(time.Second):
+		t.Fatal("Server did not start in time")
+	}
+
+	resp, err := http.Get("http://" + server.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("Expected request to succeed once started, got: %v", err)
+	}
+	resp.Body.Close()
 
 	// Trigger shutdown
 	cancel()
@@ -289,17 +400,119 @@ func TestServer_Integration(t *testing.T) {
 	}
 }
 
-// TestServer_ShutdownTimeout tests that shutdown respects timeout
+// TestServer_OnListen tests that OnListen receives the bound address before Run serves
+func TestServer_OnListen(t *testing.T) {
+	addrCh := make(chan net.Addr, 1)
+	cfg := chiserver.Config{
+		Addr:     "127.0.0.1:0",
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnListen: func(addr net.Addr) { addrCh <- addr },
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+
+	select {
+	case addr := <-addrCh:
+		if addr.String() != server.Addr().String() {
+			t.Errorf("Expected OnListen address %s, got %s", server.Addr(), addr)
+		}
+		conn, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Fatalf("Expected server to accept connections, got: %v", err)
+		}
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("OnListen was not called")
+	}
+}
+
+// TestServer_Run_CancelsRequestContextsOnShutdown tests that a streaming handler observes shutdown
+// through its request context and stops promptly
+func TestServer_Run_CancelsRequestContextsOnShutdown(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:            "127.0.0.1:0",
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ShutdownTimeout: 5 * time.Second,
+	}
+
+	streaming := make(chan struct{})
+	stopped := make(chan struct{})
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+			defer close(stopped)
+			flusher := w.(http.Flusher)
+			for i := 0; ; i++ {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(10 * time.Millisecond):
+					fmt.Fprintf(w, "tick %d\n", i)
+					flusher.Flush()
+					if i == 0 {
+						close(streaming)
+					}
+				}
+			}
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	resp, err := http.Get("http://" + server.Addr().String() + "/stream")
+	if err != nil {
+		t.Fatalf("Expected stream request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+	go io.Copy(io.Discard, resp.Body)
+	<-streaming
+
+	start := time.Now()
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected handler to observe shutdown through its context")
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt shutdown, took %s", elapsed)
+	}
+}
+
+// TestServer_ShutdownTimeout tests that shutdown respects the configured timeout
 func TestServer_ShutdownTimeout(t *testing.T) {
 	cfg := chiserver.Config{
-		Addr:   ":0",
-		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Addr:            "127.0.0.1:0",
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ShutdownTimeout: 200 * time.Millisecond,
 	}
 
+	release := make(chan struct{})
+	defer close(release)
+	entered := make(chan struct{})
+
 	server := chiserver.NewServer(cfg, func(r chi.Router) {
 		r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
 			// Simulate slow handler
-			time.Sleep(10 * time.Second)
+			close(entered)
+			<-release
 			w.WriteHeader(http.StatusOK)
 		})
 	})
@@ -310,34 +523,1144 @@ func TestServer_ShutdownTimeout(t *testing.T) {
 	go func() {
 		errCh <- server.Run(ctx)
 	}()
+	<-server.Started()
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
+	// Keep a request in flight
+	clientDone := make(chan struct{})
+	defer func() { <-clientDone }()
+	go func() {
+		defer close(clientDone)
+		resp, err := http.Get("http://" + server.Addr().String() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
 
 	// Cancel context to trigger shutdown
 	cancel()
 
-	// Server should complete shutdown within the 5 second timeout defined in Run()
 	select {
 	case err := <-errCh:
-		// Server shut down (may or may not have error depending on timing)
-		_ = err
-	case <-time.After(7 * time.Second):
-		t.Fatal("Server shutdown took longer than expected timeout")
+		if !errors.Is(err, chiserver.ErrShutdownTimeout) {
+			t.Errorf("Expected ErrShutdownTimeout, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server shutdown took longer than the configured timeout")
 	}
 }
 
-// TestConfig_DefaultValues tests Config with default/zero values
-func TestConfig_DefaultValues(t *testing.T) {
-	cfg := chiserver.Config{} // Empty config
+// TestServer_ShutdownTimeout_ForceClose tests that connections of stuck handlers are closed after the timeout
+func TestServer_ShutdownTimeout_ForceClose(t *testing.T) {
+	var logs syncBuffer
+	cfg := chiserver.Config{
+		Addr:            "127.0.0.1:0",
+		Logger:          slog.New(slog.NewTextHandler(&logs, nil)),
+		ShutdownTimeout: 200 * time.Millisecond,
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	entered := make(chan struct{})
 
 	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/stuck", func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release // ignores its context
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	clientErr := make(chan error, 1)
+	go func() {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://" + server.Addr().String() + "/stuck")
+		if err == nil {
+			resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+	<-entered
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, chiserver.ErrShutdownTimeout) {
+		t.Errorf("Expected ErrShutdownTimeout, got: %v", err)
+	}
+	select {
+	case err := <-clientErr:
+		if err == nil {
+			t.Error("Expected the stuck request's connection to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stuck connection to be closed after the shutdown timeout")
+	}
+	if out := logs.String(); !strings.Contains(out, "forcibly closing connections") || !strings.Contains(out, "connections=1") {
+		t.Errorf("Expected log of one forcibly closed connection, got: %s", out)
+	}
+}
+
+// TestServer_Shutdown_LogsDrain tests that connections still open during shutdown are logged
+// periodically until they drain
+func TestServer_Shutdown_LogsDrain(t *testing.T) {
+	var logs syncBuffer
+	entered := make(chan struct{})
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}, func(r chi.Router) {
+		r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			time.Sleep(1500 * time.Millisecond) // ignores its context
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	clientErr := make(chan error, 1)
+	go func() {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://" + server.Addr().String() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+	<-entered
+	cancel()
+
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+	if err := <-clientErr; err != nil {
+		t.Errorf("Expected the slow request to complete, got: %v", err)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "waiting for connections to drain") || !strings.Contains(out, "connections=1 active=1") {
+		t.Errorf("Expected a drain log with one active connection, got: %s", out)
+	}
+	if strings.LastIndex(out, "waiting for connections to drain") > strings.Index(out, "server gracefully stopped") {
+		t.Errorf("Expected drain logs to stop once shutdown completes, got: %s", out)
+	}
+}
+
+// TestServer_HTTPServer tests that settings made on the underlying http.Server apply and chain with
+// the server's own hooks
+func TestServer_HTTPServer(t *testing.T) {
+	type ctxKey string
+
+	values := make(chan [2]any, 1)
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
+			values <- [2]any{r.Context().Value(ctxKey("base")), r.Context().Value(ctxKey("conn"))}
+			<-r.Context().Done()
 		})
 	})
 
-	if server == nil {
-		t.Fatal("Expected server to be created with default config values")
+	var states atomic.Int32
+	hs := server.HTTPServer()
+	hs.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), ctxKey("base"), "b")
+	}
+	hs.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, ctxKey("conn"), "c")
+	}
+	hs.ConnState = func(net.Conn, http.ConnState) { states.Add(1) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	go func() {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		if resp, err := client.Get("http://" + server.Addr().String() + "/"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	if got := <-values; got != [2]any{"b", "c"} {
+		t.Errorf("Expected BaseContext and ConnContext values, got %v", got)
+	}
+
+	// The handler only returns once shutdown cancels its context.
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+	if states.Load() == 0 {
+		t.Error("Expected the custom ConnState hook to be called")
+	}
+}
+
+// TestServer_Run_AddrInUse tests that binding an already used address returns ErrAddrInUse with the address
+func TestServer_Run_AddrInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	addr := ln.Addr().String()
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   addr,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	err = server.Run(context.Background())
+	if !errors.Is(err, chiserver.ErrAddrInUse) {
+		t.Fatalf("Expected ErrAddrInUse, got: %v", err)
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("Expected the original error to be wrapped, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), addr) {
+		t.Errorf("Expected error to mention %s, got: %v", addr, err)
+	}
+}
+
+// TestServer_Run_Lifecycle tests that Run refuses to run twice concurrently or after shutdown
+func TestServer_Run_Lifecycle(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	if err := server.Run(context.Background()); !errors.Is(err, chiserver.ErrServerAlreadyRunning) {
+		t.Errorf("Expected ErrServerAlreadyRunning, got: %v", err)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Expected clean shutdown, got: %v", err)
+	}
+
+	if err := server.Run(context.Background()); !errors.Is(err, chiserver.ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed, got: %v", err)
+	}
+}
+
+// TestServer_Stop tests that Stop shuts down a running server from inside a handler and Run returns nil
+func TestServer_Stop(t *testing.T) {
+	var logs syncBuffer
+	var server *chiserver.Server
+	stopped := make(chan error, 1)
+	server = chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	}, func(r chi.Router) {
+		r.Post("/admin/shutdown", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			go func() { stopped <- server.Stop(context.Background()) }()
+		})
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(context.Background())
+	}()
+	<-server.Started()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Post("http://"+server.Addr().String()+"/admin/shutdown", "", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Expected Run to return nil after Stop, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Expected Stop to return nil, got: %v", err)
+	}
+	if !strings.Contains(logs.String(), `"reason":"stopped"`) {
+		t.Errorf("Expected stopped reason, got: %s", logs.String())
+	}
+	if err := server.Stop(context.Background()); err != nil {
+		t.Errorf("Expected repeated Stop to return nil, got: %v", err)
+	}
+}
+
+// TestServer_Stop_BeforeRun tests that a server stopped before running refuses to run
+func TestServer_Stop_BeforeRun(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	if err := server.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected Stop to succeed, got: %v", err)
+	}
+	if err := server.Run(context.Background()); !errors.Is(err, chiserver.ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed, got: %v", err)
+	}
+}
+
+// TestServer_Run_RetryAfterListenError tests that Run can be retried when listening failed
+func TestServer_Run_RetryAfterListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   addr,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	if err := server.Run(context.Background()); !errors.Is(err, chiserver.ErrAddrInUse) {
+		t.Fatalf("Expected ErrAddrInUse, got: %v", err)
+	}
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := server.Run(ctx); err != nil {
+		t.Errorf("Expected retry to run once the address is free, got: %v", err)
+	}
+}
+
+// slowCloseListener is a listener whose Close waits for release, holding Run inside a failing
+// start
+type slowCloseListener struct {
+	net.Listener
+	closing chan struct{}
+	release chan struct{}
+}
+
+func (l slowCloseListener) Close() error {
+	close(l.closing)
+	<-l.release
+	return l.Listener.Close()
+}
+
+// TestServer_Stop_DuringListenError tests that Stop during a Run that fails to listen returns
+// Run's error and leaves the server closed
+func TestServer_Stop_DuringListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer busy.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	sl := slowCloseListener{Listener: ln, closing: make(chan struct{}), release: make(chan struct{})}
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	server := chiserver.NewServer(chiserver.Config{
+		Listener:         sl,
+		CertFile:         certFile,
+		KeyFile:          keyFile,
+		RedirectHTTPAddr: busy.Addr().String(),
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- server.Run(context.Background())
+	}()
+	<-sl.closing
+
+	stopErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		stopErr <- server.Stop(ctx)
+	}()
+	// Let Stop find the server running before the failed start completes.
+	time.Sleep(50 * time.Millisecond)
+	close(sl.release)
+
+	if err := <-runErr; !errors.Is(err, chiserver.ErrAddrInUse) {
+		t.Fatalf("Expected ErrAddrInUse from Run, got: %v", err)
+	}
+	if err := <-stopErr; !errors.Is(err, chiserver.ErrAddrInUse) {
+		t.Errorf("Expected Stop to return Run's error, got: %v", err)
+	}
+	if err := server.Run(context.Background()); !errors.Is(err, chiserver.ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed after Stop, got: %v", err)
+	}
+}
+
+// TestServer_BuiltinRoutes_ConfiguratorUse tests that configurators can call Use with built-in
+// routes enabled, and that routes they register take precedence
+func TestServer_BuiltinRoutes_ConfiguratorUse(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		EnableStatus:  true,
+		EnableMetrics: true,
+		EnablePprof:   true,
+		HealthPath:    "/healthz",
+		ReadyPath:     "/readyz",
+	}, func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Configurator", "yes")
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("custom ready"))
+		})
+	})
+	handler := server.HTTPServer().Handler
+
+	for _, path := range []string{chiserver.DefaultStatusPath, chiserver.DefaultMetricsPath, chiserver.DefaultPprofPathPrefix + "/", "/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, w.Code)
+		}
+		if w.Header().Get("X-Configurator") != "yes" {
+			t.Errorf("%s: expected the configurator's middleware to run", path)
+		}
+		if path == "/readyz" && w.Body.String() != "custom ready" {
+			t.Errorf("Expected the configurator's /readyz route, got %q", w.Body.String())
+		}
+	}
+}
+
+// TestConfig_DefaultValues tests Config with default/zero values
+func TestConfig_DefaultValues(t *testing.T) {
+	cfg := chiserver.Config{} // Empty config
+
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	if server == nil {
+		t.Fatal("Expected server to be created with default config values")
+	}
+}
+
+// writeSelfSignedCert writes a self-signed certificate for 127.0.0.1 into dir
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// TestServer_Run_TLS tests serving HTTPS when a certificate and key are configured
+func TestServer_Run_TLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	cfg := chiserver.Config{
+		Addr:     "127.0.0.1:0",
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/secure", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("secure"))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	<-server.Started()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + server.Addr().String() + "/secure")
+	if err != nil {
+		t.Fatalf("Expected HTTPS request to succeed, got: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.TLS == nil {
+		t.Error("Expected response over TLS")
+	}
+	if string(body) != "secure" {
+		t.Errorf("Expected body 'secure', got %q", body)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+}
+
+// freeAddr returns a loopback address with a port that was free a moment ago
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// TestServer_Run_RedirectHTTPAddr tests that plain HTTP requests are redirected to HTTPS and both
+// listeners stop together
+func TestServer_Run_RedirectHTTPAddr(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+	redirectAddr := freeAddr(t)
+
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:             "127.0.0.1:0",
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		CertFile:         certFile,
+		KeyFile:          keyFile,
+		RedirectHTTPAddr: redirectAddr,
+	}, func(r chi.Router) {
+		r.Get("/secure", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("secure"))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	client := &http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get("http://" + redirectAddr + "/secure?x=1")
+	if err != nil {
+		t.Fatalf("Expected HTTP request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+
+	_, port, _ := net.SplitHostPort(server.Addr().String())
+	expected := "https://127.0.0.1:" + port + "/secure?x=1"
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != expected {
+		t.Errorf("Expected 301 to %s, got %d %q", expected, resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+	if _, err := client.Get("http://" + redirectAddr + "/"); err == nil {
+		t.Error("Expected the redirect listener to be closed after shutdown")
+	}
+}
+
+// seedAutocertCache stores a self-signed certificate for domain in an autocert.DirCache at dir, so
+// the manager serves it without contacting a CA
+func seedAutocertCache(t *testing.T, dir, domain string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	if err := autocert.DirCache(dir).Put(context.Background(), domain, data); err != nil {
+		t.Fatalf("Failed to seed cache: %v", err)
+	}
+}
+
+// TestServer_Run_AutoTLS tests serving a cached ACME certificate, answering challenges and
+// redirecting on the HTTP listener, and shutting both down together
+func TestServer_Run_AutoTLS(t *testing.T) {
+	cacheDir := t.TempDir()
+	seedAutocertCache(t, cacheDir, "example.com")
+	redirectAddr := freeAddr(t)
+
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AutoTLS: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist("example.com"),
+			Cache:      autocert.DirCache(cacheDir),
+		},
+		RedirectHTTPAddr: redirectAddr,
+	}, func(r chi.Router) {
+		r.Get("/secure", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("secure"))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	tlsClient := &http.Client{Transport: &http.Transport{
+		DisableKeepAlives: true,
+		TLSClientConfig:   &tls.Config{ServerName: "example.com", InsecureSkipVerify: true},
+	}}
+	resp, err := tlsClient.Get("https://" + server.Addr().String() + "/secure")
+	if err != nil {
+		t.Fatalf("Expected HTTPS request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || resp.TLS.PeerCertificates[0].Subject.CommonName != "example.com" {
+		t.Errorf("Expected the cached certificate for example.com, got %+v", resp.TLS)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err = client.Get("http://" + redirectAddr + "/.well-known/acme-challenge/token")
+	if err != nil {
+		t.Fatalf("Expected challenge request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusMovedPermanently {
+		t.Error("Expected ACME challenges to be answered rather than redirected")
+	}
+
+	resp, err = client.Get("http://" + redirectAddr + "/secure")
+	if err != nil {
+		t.Fatalf("Expected HTTP request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("Expected 301 for other paths, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+	if _, err := client.Get("http://" + redirectAddr + "/"); err == nil {
+		t.Error("Expected the challenge listener to be closed after shutdown")
+	}
+}
+
+// TestServer_Run_CustomListener tests serving on an injected Unix domain socket listener
+func TestServer_Run_CustomListener(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "server.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+
+	cfg := chiserver.Config{
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Listener: ln,
+	}
+
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("pong"))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://unix/ping")
+	if err != nil {
+		t.Fatalf("Expected request over unix socket to succeed, got: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "pong" {
+		t.Errorf("Expected body 'pong', got %q", body)
+	}
+	if server.Addr().Network() != "unix" {
+		t.Errorf("Expected unix address, got %s", server.Addr().Network())
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+}
+
+// TestServer_RegisterOnShutdown_PriorityOrder tests that shutdown hooks run in priority order
+func TestServer_RegisterOnShutdown_PriorityOrder(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	var order []string
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	server.RegisterOnShutdown(100, hook("close-db"))
+	server.RegisterOnShutdown(0, hook("deregister"))
+	server.RegisterOnShutdown(50, hook("flush-metrics"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := server.Run(ctx); err != nil {
+		t.Fatalf("Expected clean shutdown, got error: %v", err)
+	}
+
+	expected := []string{"deregister", "flush-metrics", "close-db"}
+	if strings.Join(order, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected hooks to run in order %v, got %v", expected, order)
+	}
+}
+
+// TestServer_RegisterOnShutdown_JoinsErrors tests that hook errors are returned by Run
+func TestServer_RegisterOnShutdown_JoinsErrors(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	errFlush := errors.New("flush failed")
+	errClose := errors.New("close failed")
+	server.RegisterOnShutdown(0, func(context.Context) error { return errFlush })
+	server.RegisterOnShutdown(1, func(context.Context) error { return errClose })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := server.Run(ctx)
+	if !errors.Is(err, errFlush) || !errors.Is(err, errClose) {
+		t.Errorf("Expected joined hook errors, got: %v", err)
+	}
+}
+
+// TestServer_OnShutdown_AfterShutdownTimeout tests that hooks get a live context even when
+// draining timed out
+func TestServer_OnShutdown_AfterShutdownTimeout(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:                "127.0.0.1:0",
+		Logger:              slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ShutdownTimeout:     100 * time.Millisecond,
+		ShutdownHookTimeout: time.Second,
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	entered := make(chan struct{})
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/stuck", func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release // ignores its context
+		})
+	})
+
+	var hookErr error
+	server.OnShutdown(func(ctx context.Context) error {
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	clientDone := make(chan struct{})
+	defer func() { <-clientDone }()
+	go func() {
+		defer close(clientDone)
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://" + server.Addr().String() + "/stuck")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, chiserver.ErrShutdownTimeout) {
+		t.Fatalf("Expected ErrShutdownTimeout, got: %v", err)
+	}
+	if hookErr != nil {
+		t.Errorf("Expected the hook to get a live context, got: %v", hookErr)
+	}
+}
+
+// TestServer_OnShutdown_ServeError tests that hooks run when serving fails
+func TestServer_OnShutdown_ServeError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Listener: failingListener{ln},
+	}, func(r chi.Router) {})
+
+	errFlush := errors.New("flush failed")
+	called := false
+	server.OnShutdown(func(context.Context) error {
+		called = true
+		return errFlush
+	})
+
+	err = server.Run(context.Background())
+	if !called {
+		t.Error("Expected the hook to run after the serve error")
+	}
+	if err == nil || !strings.Contains(err.Error(), "listener broken") || !errors.Is(err, errFlush) {
+		t.Errorf("Expected the serve error joined with the hook error, got: %v", err)
+	}
+}
+
+// TestServer_OnShutdown_LIFO tests that OnShutdown hooks run in reverse registration order
+// with a deadline-bound context
+func TestServer_OnShutdown_LIFO(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:            "127.0.0.1:0",
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ShutdownTimeout: time.Second,
+	}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+	var order []int
+	for i := 1; i <= 3; i++ {
+		server.OnShutdown(func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("Expected hook context to carry the shutdown deadline")
+			}
+			order = append(order, i)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := server.Run(ctx); err != nil {
+		t.Fatalf("Expected clean shutdown, got error: %v", err)
+	}
+
+	if len(order) != 3 || order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Errorf("Expected hooks to run in LIFO order [3 2 1], got %v", order)
+	}
+}
+
+// failingListener is a net.Listener whose Accept fails permanently
+type failingListener struct {
+	net.Listener
+}
+
+func (l failingListener) Accept() (net.Conn, error) {
+	return nil, errors.New("listener broken")
+}
+
+// TestServer_Run_ShutdownReason tests that lifecycle logs carry the reason Run exited
+func TestServer_Run_ShutdownReason(t *testing.T) {
+	run := func(t *testing.T, cfg chiserver.Config, ctx context.Context, onStarted func()) string {
+		var buf syncBuffer
+		cfg.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+		server := chiserver.NewServer(cfg, func(r chi.Router) {})
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- server.Run(ctx)
+		}()
+		if onStarted != nil {
+			<-server.Started()
+			onStarted()
+		}
+		select {
+		case <-errCh:
+		case <-time.After(6 * time.Second):
+			t.Fatal("Server did not stop in time")
+		}
+		return buf.String()
+	}
+
+	t.Run("context_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		logs := run(t, chiserver.Config{Addr: "127.0.0.1:0"}, ctx, nil)
+		if !strings.Contains(logs, `"reason":"context_cancelled"`) {
+			t.Errorf("Expected context_cancelled reason, got: %s", logs)
+		}
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		ctx := chiserver.WaitForSignalWith(context.Background(), syscall.SIGHUP)
+		logs := run(t, chiserver.Config{Addr: "127.0.0.1:0"}, ctx, func() {
+			p, _ := os.FindProcess(os.Getpid())
+			p.Signal(syscall.SIGHUP)
+		})
+		if !strings.Contains(logs, `"reason":"signal"`) {
+			t.Errorf("Expected signal reason, got: %s", logs)
+		}
+	})
+
+	t.Run("serve_error", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer ln.Close()
+		logs := run(t, chiserver.Config{Listener: failingListener{ln}}, context.Background(), nil)
+		if !strings.Contains(logs, `"reason":"serve_error"`) {
+			t.Errorf("Expected serve_error reason, got: %s", logs)
+		}
+	})
+}
+
+// TestServer_OnServeError tests that the callback receives the error Run returns on serve failures
+// but is not called for clean shutdowns
+func TestServer_OnServeError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	var called []error
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Listener:     failingListener{ln},
+		OnServeError: func(err error) { called = append(called, err) },
+	}, func(r chi.Router) {})
+
+	err = server.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "listener broken") {
+		t.Fatalf("Expected the serve error, got: %v", err)
+	}
+	if len(called) != 1 || called[0] != err {
+		t.Errorf("Expected OnServeError to be called once with %v, got %v", err, called)
+	}
+
+	called = nil
+	server = chiserver.NewServer(chiserver.Config{
+		Addr:         "127.0.0.1:0",
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnServeError: func(err error) { called = append(called, err) },
+	}, func(r chi.Router) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := server.Run(ctx); err != nil {
+		t.Fatalf("Expected clean shutdown, got: %v", err)
+	}
+	if len(called) != 0 {
+		t.Errorf("Expected no OnServeError call on clean shutdown, got %v", called)
+	}
+}
+
+// TestNewServer_CustomMiddlewares tests that Config.Middlewares replaces the default chain
+func TestNewServer_CustomMiddlewares(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		middlewares    []func(http.Handler) http.Handler
+		expectCorrID   bool
+		expectedStatus int
+	}{
+		"replaced": {[]func(http.Handler) http.Handler{auth}, false, http.StatusUnauthorized},
+		"extended": {append(chiserver.DefaultMiddlewares(logger), auth), true, http.StatusUnauthorized},
+	} {
+		server := chiserver.NewServer(chiserver.Config{
+			Addr:        "127.0.0.1:0",
+			Logger:      logger,
+			Middlewares: tc.middlewares,
+		}, func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- server.Run(ctx)
+		}()
+		<-server.Started()
+
+		resp, err := http.Get("http://" + server.Addr().String() + "/")
+		if err != nil {
+			t.Fatalf("%s: expected request to succeed, got: %v", name, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", name, tc.expectedStatus, resp.StatusCode)
+		}
+		if hasCorrID := resp.Header.Get(chiserver.CorrelationIDHeader) != ""; hasCorrID != tc.expectCorrID {
+			t.Errorf("%s: expected correlation header present=%v, got %v", name, tc.expectCorrID, hasCorrID)
+		}
+
+		cancel()
+		<-errCh
+	}
+}
+
+// TestNewServer_DisableDefaultMiddlewares tests that each Disable flag drops only its middleware
+func TestNewServer_DisableDefaultMiddlewares(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  chiserver.Config
+	}{
+		{"defaults", chiserver.Config{}},
+		{"request id", chiserver.Config{DisableRequestID: true}},
+		{"real ip", chiserver.Config{DisableRealIP: true}},
+		{"recoverer", chiserver.Config{DisableRecoverer: true}},
+		{"request logger", chiserver.Config{DisableRequestLogger: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.cfg.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+			var reqID, clientIP string
+			server := chiserver.NewServer(tt.cfg, func(r chi.Router) {
+				r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+					reqID = middleware.GetReqID(r.Context())
+					clientIP = middleware.GetClientIP(r.Context())
+				})
+				r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+					panic("boom")
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Forwarded-For", "1.2.3.4")
+			w := httptest.NewRecorder()
+			server.Router().ServeHTTP(w, req)
+
+			if got := reqID != ""; got != !tt.cfg.DisableRequestID {
+				t.Errorf("Expected request ID present=%v, got %q", !tt.cfg.DisableRequestID, reqID)
+			}
+			if got := clientIP != ""; got != !tt.cfg.DisableRealIP {
+				t.Errorf("Expected client IP present=%v, got %q", !tt.cfg.DisableRealIP, clientIP)
+			}
+			if w.Header().Get(chiserver.CorrelationIDHeader) == "" {
+				t.Error("Expected CorrelationID to stay in the chain")
+			}
+			if logged := strings.Contains(buf.String(), `"path":"/"`); logged != !tt.cfg.DisableRequestLogger {
+				t.Errorf("Expected request logged=%v, got log %q", !tt.cfg.DisableRequestLogger, buf.String())
+			}
+
+			recovered := func() (recovered bool) {
+				defer func() { recovered = recover() == nil }()
+				server.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+				return
+			}()
+			if recovered != !tt.cfg.DisableRecoverer {
+				t.Errorf("Expected panic recovered=%v, got %v", !tt.cfg.DisableRecoverer, recovered)
+			}
+		})
 	}
 }
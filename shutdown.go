@@ -0,0 +1,53 @@
+package chiserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// ShutdownHandler returns a handler that gracefully stops the server, e.g.
+// for internal tooling:
+//
+//	server.Router().Method(http.MethodPost, "/admin/shutdown", server.ShutdownHandler(os.Getenv("SHUTDOWN_TOKEN")))
+//
+// Requests must use POST and carry "Authorization: Bearer <token>"; others
+// get 405 or 401. Accepted requests are answered with 202 Accepted, and Stop
+// runs in the background, draining in-flight requests as usual. Anyone
+// holding the token can take the service down, so keep it secret, serve the
+// route on an internal listener or network only and always over TLS outside
+// localhost. It panics if token is empty.
+func (s *Server) ShutdownHandler(token string) http.Handler {
+	if token == "" {
+		panic("chiserver: ShutdownHandler requires a non-empty token")
+	}
+	// Comparing digests keeps the comparison constant-time regardless of the
+	// presented token's length.
+	want := sha256.Sum256([]byte(token))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			WriteError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+			return
+		}
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		got := sha256.Sum256([]byte(presented))
+		if !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			WriteError(w, r, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		s.logger.Warn("shutdown requested over HTTP",
+			slog.String("remote", clientIP(r)),
+			slog.String("correlation_id", GetCorrID(r.Context())),
+		)
+		w.WriteHeader(http.StatusAccepted)
+		// Stop waits for this request to finish, so it must not block it.
+		go s.Stop(context.Background())
+	})
+}
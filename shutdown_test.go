@@ -0,0 +1,86 @@
+package chiserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestServer_ShutdownHandler tests that only authenticated POSTs stop the server, answering 202
+func TestServer_ShutdownHandler(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+	server.Router().Handle("/admin/shutdown", server.ShutdownHandler("s3cret"))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(context.Background())
+	}()
+	<-server.Started()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	url := "http://" + server.Addr().String() + "/admin/shutdown"
+	tests := []struct {
+		name           string
+		method         string
+		auth           string
+		expectedStatus int
+	}{
+		{"no token", http.MethodPost, "", http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, "Bearer s3cret-not", http.StatusUnauthorized},
+		{"wrong scheme", http.MethodPost, "Basic s3cret", http.StatusUnauthorized},
+		{"get", http.MethodGet, "Bearer s3cret", http.StatusMethodNotAllowed},
+		{"authorized", http.MethodPost, "Bearer s3cret", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, url, nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: expected request to succeed, got: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.expectedStatus, resp.StatusCode)
+		}
+		if tt.expectedStatus != http.StatusAccepted {
+			select {
+			case err := <-errCh:
+				t.Fatalf("%s: expected the server to keep running, Run returned %v", tt.name, err)
+			default:
+			}
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to stop after an authorized request")
+	}
+}
+
+// TestServer_ShutdownHandler_EmptyToken tests that an empty token is refused
+func TestServer_ShutdownHandler_EmptyToken(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}, func(r chi.Router) {})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "non-empty token") {
+			t.Errorf("Expected a panic for an empty token, got %v", r)
+		}
+	}()
+	server.ShutdownHandler("")
+}
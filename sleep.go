@@ -0,0 +1,21 @@
+package chiserver
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep pauses for d or until ctx is done, whichever comes first. It returns
+// ctx.Err() when the context ends the wait early and nil otherwise, so
+// handlers polling or backing off stop as soon as the client goes away.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package chiserver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestSleep_Completes tests that Sleep waits for the full duration and returns nil
+func TestSleep_Completes(t *testing.T) {
+	start := time.Now()
+	if err := chiserver.Sleep(context.Background(), 50*time.Millisecond); err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected Sleep to last at least 50ms, took %s", elapsed)
+	}
+}
+
+// TestSleep_Cancelled tests that Sleep returns early with the context error
+func TestSleep_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := chiserver.Sleep(ctx, 5*time.Second)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Sleep to return early, took %s", elapsed)
+	}
+}
@@ -0,0 +1,54 @@
+package chiserver
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// spaIndex is served for client-side routes under a FileServer prefix.
+const spaIndex = "index.html"
+
+// FileServer serves the files of root under pattern, e.g. an embedded admin
+// UI at "/admin", behind the same middleware chain as the API routes.
+// Requests for missing paths without a file extension, such as client-side
+// routes of a single-page app, are answered with root's index.html, while
+// missing assets like /admin/app.js still get 404. Routes registered for more
+// specific patterns take precedence.
+func (s *Server) FileServer(pattern string, root fs.FS) {
+	prefix := strings.TrimSuffix(pattern, "/")
+	if prefix == "" {
+		s.router.Mount("/", spaFileServer(root))
+		return
+	}
+
+	s.router.Get(prefix, func(w http.ResponseWriter, r *http.Request) {
+		target := prefix + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	s.router.Mount(prefix+"/", http.StripPrefix(prefix, spaFileServer(root)))
+}
+
+// spaFileServer serves root, falling back to its index.html for missing
+// extensionless paths.
+func spaFileServer(root fs.FS) http.Handler {
+	files := http.FileServerFS(root)
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		if _, err := fs.Stat(root, name); errors.Is(err, fs.ErrNotExist) && path.Ext(name) == "" {
+			http.ServeFileFS(w, r, root, spaIndex)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,79 @@
+package chiserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// newStaticServer returns a server with an API route and an SPA mounted at /admin
+func newStaticServer() *chiserver.Server {
+	cfg := chiserver.Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/api/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("users"))
+		})
+	})
+	server.FileServer("/admin", fstest.MapFS{
+		"index.html":    {Data: []byte("<html>admin</html>")},
+		"assets/app.js": {Data: []byte("console.log('admin')")},
+	})
+	return server
+}
+
+// TestServer_FileServer tests serving files, SPA fallback and coexistence with API routes
+func TestServer_FileServer(t *testing.T) {
+	server := newStaticServer()
+
+	tests := []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{"/admin/", http.StatusOK, "admin</html>"},
+		{"/admin/assets/app.js", http.StatusOK, "console.log"},
+		{"/admin/settings/profile", http.StatusOK, "admin</html>"},
+		{"/admin/assets/missing.js", http.StatusNotFound, ""},
+		{"/api/users", http.StatusOK, "users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.Router().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("Expected body to contain %q, got %q", tt.contains, w.Body.String())
+			}
+			if w.Header().Get(chiserver.CorrelationIDHeader) == "" {
+				t.Error("Expected static responses to pass through the correlation middleware")
+			}
+		})
+	}
+}
+
+// TestServer_FileServer_RedirectsPrefix tests that the bare prefix redirects to its directory form
+func TestServer_FileServer_RedirectsPrefix(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	w := httptest.NewRecorder()
+	newStaticServer().Router().ServeHTTP(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected 301, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/admin/" {
+		t.Errorf("Expected Location /admin/, got %q", loc)
+	}
+}
@@ -0,0 +1,72 @@
+package chiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// DefaultStatusPath is where the status endpoint is mounted by default.
+const DefaultStatusPath = "/status"
+
+// Status is the body served by the status endpoint.
+type Status struct {
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	InFlight      int64   `json:"in_flight"`
+	RequestsTotal int64   `json:"requests_total"`
+	Goroutines    int     `json:"goroutines"`
+}
+
+// countRequests tracks in-flight and completed requests.
+func (s *Server) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer func() {
+			s.inFlight.Add(-1)
+			s.requestsTotal.Add(1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Status returns a snapshot of the server's runtime statistics. The request
+// currently being served counts as in flight, not as completed.
+func (s *Server) Status() Status {
+	return Status{
+		UptimeSeconds: s.uptime().Seconds(),
+		InFlight:      s.inFlight.Load(),
+		RequestsTotal: s.requestsTotal.Load(),
+		Goroutines:    runtime.NumGoroutine(),
+	}
+}
+
+// RequestCount returns the number of requests the server has completed.
+func (s *Server) RequestCount() int64 {
+	return s.requestsTotal.Load()
+}
+
+// ConcurrencyLimiter returns the limiter installed for
+// Config.MaxConcurrentRequests, e.g. to export its in-flight count as a
+// metric, or nil when no cap is configured.
+func (s *Server) ConcurrencyLimiter() *ConcurrencyLimiter {
+	return s.concurrency
+}
+
+// uptime returns how long the server has been running, or zero before Run.
+func (s *Server) uptime() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.startedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.startedAt)
+}
+
+// StatusHandler serves Status as JSON.
+func (s *Server) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Status())
+	})
+}
@@ -0,0 +1,144 @@
+package chiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestServer_StatusEndpoint tests that /status reports uptime and request counters
+func TestServer_StatusEndpoint(t *testing.T) {
+	cfg := chiserver.Config{
+		Addr:         "127.0.0.1:0",
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		EnableStatus: true,
+	}
+
+	server := chiserver.NewServer(cfg, func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("pong"))
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	<-server.Started()
+
+	baseURL := "http://" + server.Addr().String()
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(baseURL + "/ping")
+		if err != nil {
+			t.Fatalf("Expected request to succeed, got: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(baseURL + chiserver.DefaultStatusPath)
+	if err != nil {
+		t.Fatalf("Expected status request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var status chiserver.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Expected JSON status body, got: %v", err)
+	}
+
+	if status.UptimeSeconds <= 0 {
+		t.Errorf("Expected positive uptime, got %f", status.UptimeSeconds)
+	}
+	if status.RequestsTotal != 3 {
+		t.Errorf("Expected 3 completed requests, got %d", status.RequestsTotal)
+	}
+	if status.InFlight != 1 {
+		t.Errorf("Expected only the status request in flight, got %d", status.InFlight)
+	}
+	if status.Goroutines <= 0 {
+		t.Errorf("Expected positive goroutine count, got %d", status.Goroutines)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got error: %v", err)
+	}
+}
+
+// TestServer_Status_BeforeRun tests that Status reports zero values before Run
+func TestServer_Status_BeforeRun(t *testing.T) {
+	server := chiserver.NewServer(chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {})
+
+	if status := server.Status(); status.UptimeSeconds != 0 || status.RequestsTotal != 0 {
+		t.Errorf("Expected zero status before Run, got %+v", status)
+	}
+}
+
+// TestServer_StoppedSummary tests that the final log line reports uptime and the requests served
+func TestServer_StoppedSummary(t *testing.T) {
+	var logs syncBuffer
+	server := chiserver.NewServer(chiserver.Config{
+		Addr:   "127.0.0.1:0",
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	}, func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	<-server.Started()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for range 3 {
+		resp, err := client.Get("http://" + server.Addr().String() + "/ping")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Expected clean shutdown, got: %v", err)
+	}
+
+	if got := server.RequestCount(); got != 3 {
+		t.Errorf("Expected RequestCount 3, got %d", got)
+	}
+
+	var stopped struct {
+		Msg      string `json:"msg"`
+		Uptime   int64  `json:"uptime"`
+		Requests int64  `json:"requests"`
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if strings.Contains(line, "server gracefully stopped") {
+			if err := json.Unmarshal([]byte(line), &stopped); err != nil {
+				t.Fatalf("Failed to parse log record: %v", err)
+			}
+		}
+	}
+	if stopped.Msg == "" {
+		t.Fatalf("Expected a stopped log line, got: %s", logs.String())
+	}
+	if stopped.Requests != 3 || stopped.Uptime <= 0 {
+		t.Errorf("Expected requests=3 and a positive uptime, got %+v", stopped)
+	}
+}
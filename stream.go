@@ -0,0 +1,68 @@
+package chiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StreamJSONArray writes items to w as a JSON array, encoding each element as
+// it arrives instead of buffering the whole response. The array is closed when
+// items is closed. Output is flushed whenever the writer has caught up with
+// the producer, so clients see elements as soon as they are available.
+//
+// StreamJSONArray runs until items is closed or a write fails; use
+// StreamJSONArrayContext to also stop when the request is cancelled.
+func StreamJSONArray(w http.ResponseWriter, items <-chan any) error {
+	return StreamJSONArrayContext(context.Background(), w, items)
+}
+
+// StreamJSONArrayContext is StreamJSONArray bound to ctx, typically the
+// request context. If ctx is cancelled before items is closed, it stops and
+// returns ctx.Err(); the response is left as an incomplete array.
+func StreamJSONArrayContext(ctx context.Context, w http.ResponseWriter, items <-chan any) error {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case item, ok := <-items:
+			if !ok {
+				_, err := w.Write([]byte("]"))
+				flush()
+				return err
+			}
+
+			data, err := json.Marshal(item)
+			if err != nil {
+				return fmt.Errorf("encode stream element: %w", err)
+			}
+			if !first {
+				data = append([]byte(","), data...)
+			}
+			first = false
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+
+			if len(items) == 0 {
+				flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package chiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// flushRecorder is a ResponseWriter that signals every flush and snapshots the body
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	flushes chan string
+}
+
+func (f *flushRecorder) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ResponseRecorder.Write(b)
+}
+
+func (f *flushRecorder) Flush() {
+	f.mu.Lock()
+	body := f.Body.String()
+	f.mu.Unlock()
+	f.flushes <- body
+}
+
+// TestStreamJSONArray_StreamsIncrementally tests that elements are flushed as they arrive
+func TestStreamJSONArray_StreamsIncrementally(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 10)}
+	items := make(chan any)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chiserver.StreamJSONArray(w, items)
+	}()
+
+	items <- map[string]int{"id": 1}
+	select {
+	case body := <-w.flushes:
+		if body != `[{"id":1}` {
+			t.Errorf("Expected first element to be flushed, got %q", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a flush after the first element")
+	}
+
+	items <- map[string]int{"id": 2}
+	items <- map[string]int{"id": 3}
+	close(items)
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var decoded []map[string]int
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", w.Body.String(), err)
+	}
+	if len(decoded) != 3 || decoded[2]["id"] != 3 {
+		t.Errorf("Expected three elements, got %v", decoded)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json content type, got %q", ct)
+	}
+}
+
+// TestStreamJSONArray_EmptyChannel tests that a closed channel produces an empty array
+func TestStreamJSONArray_EmptyChannel(t *testing.T) {
+	w := httptest.NewRecorder()
+	items := make(chan any)
+	close(items)
+
+	if err := chiserver.StreamJSONArray(w, items); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if w.Body.String() != "[]" {
+		t.Errorf("Expected [], got %q", w.Body.String())
+	}
+}
+
+// TestStreamJSONArrayContext_Cancelled tests that cancellation stops the stream
+func TestStreamJSONArrayContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := chiserver.StreamJSONArrayContext(ctx, httptest.NewRecorder(), make(chan any))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package chiserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Key to use when setting the timeout state shared with RouteTimeout.
+type ctxKeyTimeout int
+
+const timeoutKey ctxKeyTimeout = 0
+
+// Timeout is a middleware that bounds handler execution to d. The request
+// context carries the deadline; if the handler has not finished when it
+// expires the client gets 503 Service Unavailable and anything the handler
+// writes afterwards fails with http.ErrHandlerTimeout. Routes can replace the
+// deadline with RouteTimeout.
+//
+// Responses are buffered until the handler returns, so streaming handlers
+// should not run under Timeout unless RouteTimeout lifts it for them.
+// Informational 1xx responses such as 103 Early Hints are sent right away.
+// Handler panics are re-raised on the serving goroutine for Recoverer.
+func Timeout(d time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			// base ends when Timeout returns, so handlers running under a
+			// RouteTimeout override stop once their response is abandoned.
+			base, cancelBase := context.WithCancel(r.Context())
+			defer cancelBase()
+			st := &timeoutState{
+				start: time.Now(),
+				base:  base,
+				timer: time.NewTimer(d),
+				tw:    &timeoutWriter{w: w, h: make(http.Header)},
+			}
+			defer st.timer.Stop()
+
+			ctx, cancel := context.WithDeadline(r.Context(), st.start.Add(d))
+			defer cancel()
+			ctx = context.WithValue(ctx, timeoutKey, st)
+			// chi recycles its routing context once Timeout returns, while
+			// an abandoned handler may still be routing, so the handler
+			// routes on a copy that is copied back once it finished.
+			rctx := chi.RouteContext(ctx)
+			var clone *chi.Context
+			if rctx != nil {
+				clone = cloneRouteContext(rctx)
+				ctx = context.WithValue(ctx, chi.RouteCtxKey, clone)
+			}
+			finished := func() {
+				if rctx != nil {
+					copyRouteContext(rctx, clone)
+				}
+			}
+
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(st.tw, r.WithContext(ctx))
+				close(done)
+			}()
+			wait := func() {
+				select {
+				case p := <-panicked:
+					panic(p)
+				case <-done:
+					finished()
+				}
+			}
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				finished()
+				st.tw.finish()
+			case <-st.timer.C:
+				if !st.tw.expire(true) {
+					wait()
+				}
+			case <-r.Context().Done():
+				if !st.tw.expire(false) {
+					wait()
+				}
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RouteTimeout replaces the deadline of an enclosing Timeout for a route or
+// group, counted from when Timeout started, so specific endpoints can run
+// longer or shorter than the global limit:
+//
+//	r.With(chiserver.RouteTimeout(10 * time.Minute)).Get("/export", export)
+//
+// A zero or negative d lifts the timeout entirely and streams the response
+// instead of buffering it. The innermost RouteTimeout wins. Without an
+// enclosing Timeout, it behaves like Timeout(d).
+func RouteTimeout(d time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		standalone := next
+		if d > 0 {
+			standalone = Timeout(d)(next)
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			st, ok := r.Context().Value(timeoutKey).(*timeoutState)
+			if !ok {
+				standalone.ServeHTTP(w, r)
+				return
+			}
+
+			// The enclosing deadline cannot be extended, so start from a
+			// context without it and follow the request's own cancellation.
+			ctx := context.WithoutCancel(r.Context())
+			var cancel context.CancelFunc
+			if deadline, ok := st.override(d); ok {
+				ctx, cancel = context.WithDeadline(ctx, deadline)
+			} else {
+				ctx, cancel = context.WithCancel(ctx)
+			}
+			defer cancel()
+			stop := context.AfterFunc(st.base, cancel)
+			defer stop()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// timeoutState is the timer of one request under Timeout.
+type timeoutState struct {
+	start time.Time
+	base  context.Context
+	timer *time.Timer
+	tw    *timeoutWriter
+}
+
+// override moves the deadline to d after start, or lifts it when d is not
+// positive, and returns the new deadline if any.
+func (st *timeoutState) override(d time.Duration) (time.Time, bool) {
+	if d <= 0 {
+		if st.tw.stream() {
+			st.timer.Stop()
+		}
+		return time.Time{}, false
+	}
+	deadline := st.start.Add(d)
+	st.timer.Reset(time.Until(deadline))
+	return deadline, true
+}
+
+// timeoutWriter buffers the response until the handler returns, or passes it
+// through once streaming.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+	streaming   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	if tw.streaming {
+		return tw.w.Header()
+	}
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.streaming {
+		tw.w.WriteHeader(code)
+		return
+	}
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		tw.informLocked(code)
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.streaming {
+		return tw.w.Write(p)
+	}
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// Flush sends streamed responses to the client; buffered ones are only sent
+// when the handler returns.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if f, ok := tw.w.(http.Flusher); tw.streaming && ok {
+		f.Flush()
+	}
+}
+
+// stream switches to passing writes through, sending what was buffered so
+// far. It reports false if the request has already timed out.
+func (tw *timeoutWriter) stream() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return false
+	}
+	tw.flushLocked()
+	tw.streaming = true
+	return true
+}
+
+// finish sends the buffered response once the handler returned.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.streaming {
+		return
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+	}
+	tw.flushLocked()
+}
+
+// informLocked sends an informational response with the headers set so far,
+// leaving the client's header map as it was for the final response.
+func (tw *timeoutWriter) informLocked(code int) {
+	dst := tw.w.Header()
+	saved := dst.Clone()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	tw.w.WriteHeader(code)
+	clear(dst)
+	for k, vv := range saved {
+		dst[k] = vv
+	}
+}
+
+// flushLocked copies the buffered headers and, once written, the status and
+// body to the client.
+func (tw *timeoutWriter) flushLocked() {
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	if !tw.wroteHeader {
+		return
+	}
+	tw.w.WriteHeader(tw.code)
+	tw.w.Write(tw.buf.Bytes())
+	tw.buf.Reset()
+}
+
+// expire answers with 503, with a body if the deadline passed rather than
+// the client going away. It reports false when the response is streaming and
+// the handler must be waited for instead.
+func (tw *timeoutWriter) expire(deadline bool) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.streaming {
+		return false
+	}
+	tw.timedOut = true
+	tw.w.WriteHeader(http.StatusServiceUnavailable)
+	if deadline {
+		tw.w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+	}
+	return true
+}
+
+// cloneRouteContext copies the routing state of rctx into a new context.
+func cloneRouteContext(rctx *chi.Context) *chi.Context {
+	c := chi.NewRouteContext()
+	c.Routes = rctx.Routes
+	c.RouteMethod = rctx.RouteMethod
+	copyRouteContext(c, rctx)
+	return c
+}
+
+// copyRouteContext copies the routing state matched so far from src to dst,
+// so middlewares reading the route pattern after the handler see it.
+func copyRouteContext(dst, src *chi.Context) {
+	dst.RoutePath = src.RoutePath
+	dst.URLParams.Keys = slices.Clone(src.URLParams.Keys)
+	dst.URLParams.Values = slices.Clone(src.URLParams.Values)
+	dst.RoutePatterns = slices.Clone(src.RoutePatterns)
+}
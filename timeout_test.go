@@ -0,0 +1,312 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestTimeout_SlowHandler tests that a slow handler gets a 503 which RequestLogger records
+func TestTimeout_SlowHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := chiserver.RequestLogger(logger)(chiserver.Timeout(20 * time.Millisecond)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			w.Write([]byte("too late"))
+		}),
+	))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "too late") {
+		t.Error("Expected late writes to be discarded")
+	}
+	if !strings.Contains(buf.String(), `"status":503`) {
+		t.Errorf("Expected log to record status 503, got: %s", buf.String())
+	}
+}
+
+// TestTimeout_FastHandler tests that handlers finishing in time are unaffected
+func TestTimeout_FastHandler(t *testing.T) {
+	handler := chiserver.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected request context to carry a deadline")
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fast", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != "done" {
+		t.Errorf("Expected 201 'done', got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestRouteTimeout_OverridesGlobalTimeout tests that routes can run longer or shorter than the
+// enclosing Timeout, with the context deadline following the override
+func TestRouteTimeout_OverridesGlobalTimeout(t *testing.T) {
+	sleep := func(d time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(d):
+			}
+			w.Write([]byte("done"))
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Use(chiserver.Timeout(50 * time.Millisecond))
+	r.Get("/default", sleep(100*time.Millisecond))
+	r.With(chiserver.RouteTimeout(time.Second)).Get("/export", func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) < 500*time.Millisecond {
+			t.Errorf("Expected the extended deadline in the context, got %v %v", deadline, ok)
+		}
+		sleep(100*time.Millisecond)(w, r)
+	})
+	r.With(chiserver.RouteTimeout(10*time.Millisecond)).Get("/quick", sleep(30*time.Millisecond))
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/default", http.StatusServiceUnavailable},
+		{"/export", http.StatusOK},
+		{"/quick", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.expectedStatus, w.Code)
+		}
+	}
+}
+
+// TestRouteTimeout_LiftsTimeoutAndStreams tests that a non-positive override disables the timeout
+// and writes through without buffering
+func TestRouteTimeout_LiftsTimeoutAndStreams(t *testing.T) {
+	flushed := make(chan struct{})
+	release := make(chan struct{})
+
+	r := chi.NewRouter()
+	r.Use(chiserver.Timeout(20 * time.Millisecond))
+	r.With(chiserver.RouteTimeout(0)).Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("Expected no deadline once the timeout is lifted")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		close(flushed)
+		<-release
+		w.Write([]byte("second\n"))
+	})
+
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	}()
+
+	<-flushed
+	time.Sleep(50 * time.Millisecond) // past the global timeout
+	close(release)
+	<-done
+
+	if w.Code != http.StatusOK || w.Body.String() != "first\nsecond\n" || !w.Flushed {
+		t.Errorf("Expected the streamed response to pass through, got %d %q flushed=%v", w.Code, w.Body.String(), w.Flushed)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected streamed headers to reach the client, got %q", ct)
+	}
+}
+
+// TestRouteTimeout_Standalone tests that RouteTimeout without an enclosing Timeout enforces its own
+func TestRouteTimeout_Standalone(t *testing.T) {
+	handler := chiserver.RouteTimeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+// TestTimeout_InformationalResponse tests that 1xx responses are sent right away and the final
+// status still follows
+func TestTimeout_InformationalResponse(t *testing.T) {
+	srv := httptest.NewServer(chiserver.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Del("Link")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("done"))
+	})))
+	defer srv.Close()
+
+	var hints []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, header.Get("Link"))
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if len(hints) != 1 || hints[0] != "</app.css>; rel=preload" {
+		t.Errorf("Expected one 103 response with the Link header, got %v", hints)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Errorf("Expected final 200 'done', got %d %q", resp.StatusCode, body)
+	}
+	if link := resp.Header.Get("Link"); link != "" {
+		t.Errorf("Expected the deleted Link header to be left out of the final response, got %q", link)
+	}
+}
+
+// TestRouteTimeout_PanicWhileStreaming tests that a panic after streaming started aborts the
+// response instead of appending an error to it
+func TestRouteTimeout_PanicWhileStreaming(t *testing.T) {
+	var logs syncBuffer
+	r := chi.NewRouter()
+	r.Use(chiserver.Recoverer(slog.New(slog.NewJSONHandler(&logs, nil))))
+	r.Use(chiserver.Timeout(time.Second))
+	r.With(chiserver.RouteTimeout(0)).Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial\n"))
+		w.(http.Flusher).Flush()
+		panic("boom")
+	})
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatalf("Expected the response to start, got: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	srv.Close()
+
+	if err == nil {
+		t.Error("Expected the aborted response to fail to read")
+	}
+	if string(body) != "partial\n" {
+		t.Errorf("Expected only the streamed part, got %q", body)
+	}
+	if !strings.Contains(logs.String(), `"panic":"boom"`) {
+		t.Errorf("Expected the panic to be logged, got: %s", logs.String())
+	}
+}
+
+// TestRouteTimeout_LiftRacesTimer tests that lifting the timeout as it expires either streams
+// the whole response or answers 503, never a mix
+func TestRouteTimeout_LiftRacesTimer(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(chiserver.Timeout(time.Millisecond))
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Millisecond)
+			next.ServeHTTP(w, r)
+		})
+	}, chiserver.RouteTimeout(0)).Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("second\n"))
+	})
+
+	for range 200 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		switch {
+		case w.Code == http.StatusOK && w.Body.String() == "first\nsecond\n":
+		case w.Code == http.StatusServiceUnavailable && !strings.Contains(w.Body.String(), "first"):
+		default:
+			t.Fatalf("Expected a full stream or a 503, got %d %q", w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestServer_HandlerTimeout_KeepsRoutePattern tests that metrics and tracing still see the route
+// pattern when the handler runs under HandlerTimeout
+func TestServer_HandlerTimeout_KeepsRoutePattern(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	server := chiserver.NewServer(chiserver.Config{
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		HandlerTimeout: time.Second,
+		EnableMetrics:  true,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}, func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(chi.URLParam(r, "id")))
+		})
+	})
+	handler := server.HTTPServer().Handler
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if w.Body.String() != "42" {
+		t.Fatalf("Expected the URL param in the body, got %q", w.Body.String())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET /users/{id}" {
+		t.Fatalf("Expected one span named 'GET /users/{id}', got %v", spans)
+	}
+	var route string
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "http.route" {
+			route = kv.Value.AsString()
+		}
+	}
+	if route != "/users/{id}" {
+		t.Errorf("Expected http.route '/users/{id}', got %q", route)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, chiserver.DefaultMetricsPath, nil))
+	if metrics := w.Body.String(); !strings.Contains(metrics, `http_requests_total{method="GET",route="/users/{id}",status="200"} 1`) {
+		t.Errorf("Expected the request counted under its route pattern, got:\n%s", metrics)
+	}
+}
@@ -0,0 +1,30 @@
+package chiserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ServerTimingHeader is the trailer used to report total handler time.
+const ServerTimingHeader = "Server-Timing"
+
+// ServerTimingTrailer is a middleware that reports the total request duration
+// as a Server-Timing trailer once the handler has finished writing the body.
+// It is a no-op for HTTP/1.0 requests, which cannot carry trailers.
+func ServerTimingTrailer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.ProtoAtLeast(1, 1) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		w.Header().Add("Trailer", ServerTimingHeader)
+
+		next.ServeHTTP(w, r)
+
+		dur := float64(time.Since(start).Microseconds()) / 1000
+		w.Header().Set(ServerTimingHeader, "total;dur="+strconv.FormatFloat(dur, 'f', 3, 64))
+	})
+}
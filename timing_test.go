@@ -0,0 +1,54 @@
+package chiserver_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestServerTimingTrailer_HTTP2 tests that the total duration is sent as a trailer over HTTP/2
+func TestServerTimingTrailer_HTTP2(t *testing.T) {
+	handler := chiserver.ServerTimingTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk-1"))
+		w.(http.Flusher).Flush()
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte("chunk-2"))
+	}))
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("Expected HTTP/2 response, got %s", resp.Proto)
+	}
+
+	// Trailers are only populated once the body has been fully read
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "chunk-1chunk-2" {
+		t.Errorf("Expected full body, got %q", body)
+	}
+
+	timing := resp.Trailer.Get(chiserver.ServerTimingHeader)
+	durStr, ok := strings.CutPrefix(timing, "total;dur=")
+	if !ok {
+		t.Fatalf("Expected Server-Timing trailer, got %q", timing)
+	}
+	dur, err := strconv.ParseFloat(durStr, 64)
+	if err != nil || dur <= 0 {
+		t.Errorf("Expected positive duration, got %q", durStr)
+	}
+}
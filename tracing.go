@@ -0,0 +1,60 @@
+package chiserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies this package as the instrumentation scope of its spans.
+const tracerName = "github.com/pmatteo/chi_server"
+
+// Tracing is a middleware starting a server span per request with tracers from tp.
+// An incoming W3C traceparent header is honoured so the span joins the upstream
+// trace. The span is named "METHOD /route/{pattern}" once routing is done and
+// carries the response status and, when CorrelationID ran first, the correlation ID.
+// The span's sampling decision is stored for IsSampled.
+func Tracing(tp trace.TracerProvider) func(next http.Handler) http.Handler {
+	tracer := tp.Tracer(tracerName)
+	propagator := propagation.TraceContext{}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+			ctx = ContextWithSampled(ctx, span.SpanContext().IsSampled())
+
+			if corrID := GetCorrID(ctx); corrID != "" {
+				span.SetAttributes(attribute.String("correlation_id", corrID))
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+			}
+
+			status := ww.Status()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
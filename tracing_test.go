@@ -0,0 +1,91 @@
+package chiserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pmatteo/chi_server"
+)
+
+// TestTracing_Span tests that a server span is named from the route pattern and joins the upstream trace
+func TestTracing_Span(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := chi.NewRouter()
+	r.Use(chiserver.CorrelationID)
+	r.Use(chiserver.Tracing(tp))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(chiserver.CorrelationIDHeader, "corr-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "GET /users/{id}" {
+		t.Errorf("Expected span name 'GET /users/{id}', got '%s'", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected span to join upstream trace, got trace ID %s", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("Expected parent span ID 00f067aa0ba902b7, got %s", got)
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected error status for 500 response, got %v", span.Status().Code)
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["correlation_id"].AsString(); got != "corr-123" {
+		t.Errorf("Expected correlation_id attribute 'corr-123', got '%s'", got)
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusInternalServerError {
+		t.Errorf("Expected status code attribute 500, got %d", got)
+	}
+}
+
+// TestTracing_Sampled tests that the span's sampling decision is readable with IsSampled
+func TestTracing_Sampled(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampler  sdktrace.Sampler
+		expected bool
+	}{
+		{"sampled", sdktrace.AlwaysSample(), true},
+		{"not sampled", sdktrace.NeverSample(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(tt.sampler))
+
+			var sampled bool
+			handler := chiserver.Tracing(tp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sampled = chiserver.IsSampled(r.Context())
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if sampled != tt.expected {
+				t.Errorf("Expected IsSampled %v, got %v", tt.expected, sampled)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package chiserver
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Paths of the browser and crawler endpoints NewServer always serves.
+const (
+	FaviconPath = "/favicon.ico"
+	RobotsPath  = "/robots.txt"
+)
+
+// DefaultRobotsTxt disallows all crawling; it is served when Config.RobotsTxt is empty.
+const DefaultRobotsTxt = "User-agent: *\nDisallow: /\n"
+
+// wellKnownCacheControl lets clients cache the favicon and robots.txt for a week.
+const wellKnownCacheControl = "public, max-age=604800"
+
+// FaviconHandler serves icon with long-lived cache headers, or 204 No Content
+// when icon is empty so browsers stop asking without a 404.
+func FaviconHandler(icon []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", wellKnownCacheControl)
+		if len(icon) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(icon))
+		w.Header().Set("Content-Length", strconv.Itoa(len(icon)))
+		w.Write(icon)
+	})
+}
+
+// RobotsHandler serves body as robots.txt with long-lived cache headers,
+// falling back to DefaultRobotsTxt when body is empty.
+func RobotsHandler(body string) http.Handler {
+	if body == "" {
+		body = DefaultRobotsTxt
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", wellKnownCacheControl)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(body))
+	})
+}
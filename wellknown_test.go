@@ -0,0 +1,106 @@
+package chiserver_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/pmatteo/chi_server"
+	"github.com/pmatteo/chi_server/chiservertest"
+)
+
+// TestServer_FaviconAndRobots tests that the configured favicon and robots.txt are served with cache headers
+func TestServer_FaviconAndRobots(t *testing.T) {
+	icon := []byte("\x00\x00\x01\x00fake-icon")
+	base, client := chiservertest.StartTestServer(t, chiserver.Config{
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Favicon:   icon,
+		RobotsTxt: "User-agent: *\nAllow: /\n",
+	}, func(r chi.Router) {})
+
+	tests := []struct {
+		path     string
+		expected []byte
+	}{
+		{chiserver.FaviconPath, icon},
+		{chiserver.RobotsPath, []byte("User-agent: *\nAllow: /\n")},
+	}
+
+	for _, tt := range tests {
+		resp, err := client.Get(base + tt.path)
+		if err != nil {
+			t.Fatalf("Expected request to %s to succeed, got: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.path, resp.StatusCode)
+		}
+		if !bytes.Equal(body, tt.expected) {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.expected, body)
+		}
+		if resp.Header.Get("Cache-Control") == "" {
+			t.Errorf("%s: expected cache headers", tt.path)
+		}
+	}
+}
+
+// TestServer_FaviconAndRobotsDefaults tests the 204 favicon and disallow-all robots.txt defaults,
+// and that neither request is logged
+func TestServer_FaviconAndRobotsDefaults(t *testing.T) {
+	var buf syncBuffer
+	base, client := chiservertest.StartTestServer(t, chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+	}, func(r chi.Router) {})
+
+	resp, err := client.Get(base + chiserver.FaviconPath)
+	if err != nil {
+		t.Fatalf("Expected favicon request to succeed, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected favicon status 204, got %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get(base + chiserver.RobotsPath)
+	if err != nil {
+		t.Fatalf("Expected robots request to succeed, got: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != chiserver.DefaultRobotsTxt {
+		t.Errorf("Expected default robots.txt, got %q", body)
+	}
+
+	if strings.Contains(buf.String(), "msg=request") {
+		t.Errorf("Expected favicon and robots requests not to be logged, got: %s", buf.String())
+	}
+}
+
+// TestServer_RobotsOverriddenByRoutes tests that routes can call Use and replace the built-in robots.txt
+func TestServer_RobotsOverriddenByRoutes(t *testing.T) {
+	base, client := chiservertest.StartTestServer(t, chiserver.Config{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, func(r chi.Router) {
+		r.Use(chiserver.DefaultContentType("text/plain"))
+		r.Get(chiserver.RobotsPath, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("custom"))
+		})
+	})
+
+	resp, err := client.Get(base + chiserver.RobotsPath)
+	if err != nil {
+		t.Fatalf("Expected robots request to succeed, got: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "custom" {
+		t.Errorf("Expected the route's robots.txt, got %q", body)
+	}
+}